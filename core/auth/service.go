@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/riltech/centurion/core/storage"
+)
+
+// Key of the signing secret in the store
+const storeKey = "token_secret"
+
+// Describes an auth service interface
+type IService interface {
+	// Issues a secret token for a given player
+	Issue(playerID string) string
+	// Verifies a given token and returns the ID of the player
+	// it was issued for
+	Verify(token string) (string, error)
+}
+
+// Service implementation
+// Tokens are the player ID signed with HMAC-SHA256
+// so they can be verified without any lookup
+type Service struct {
+	secret []byte
+}
+
+// Interface check
+var _ IService = (*Service)(nil)
+
+// Returns the signature of a given player ID
+func (s Service) sign(playerID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(playerID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s Service) Issue(playerID string) string {
+	return fmt.Sprintf("%s.%s", playerID, s.sign(playerID))
+}
+
+func (s Service) Verify(token string) (string, error) {
+	index := strings.LastIndex(token, ".")
+	if index < 1 {
+		return "", fmt.Errorf("Token is malformed")
+	}
+	playerID, signature := token[:index], token[index+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(playerID))) {
+		return "", fmt.Errorf("Token signature is invalid")
+	}
+	return playerID, nil
+}
+
+// Returns a secret for signing tokens
+// The secret is read from the store if there is one, so tokens
+// stay valid after a restart. Otherwise a random secret is generated
+func LoadOrCreateSecret(store storage.IStore) ([]byte, error) {
+	var secret []byte
+	if store != nil {
+		found, err := store.Load(storeKey, &secret)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return secret, nil
+		}
+	}
+	secret = make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if store != nil {
+		if err := store.Save(storeKey, secret); err != nil {
+			return nil, err
+		}
+	}
+	return secret, nil
+}
+
+// Constructor for the auth service
+func NewService(secret []byte) IService {
+	return &Service{secret}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/riltech/centurion/core/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService(t *testing.T) {
+	service := NewService([]byte("secret"))
+	token := service.Issue("xxx")
+	ID, err := service.Verify(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "xxx", ID)
+
+	_, err = service.Verify("xxx")
+	assert.NotNil(t, err)
+	_, err = service.Verify("yyy" + token[3:])
+	assert.NotNil(t, err)
+	_, err = NewService([]byte("other")).Verify(token)
+	assert.NotNil(t, err)
+}
+
+func TestLoadOrCreateSecret(t *testing.T) {
+	first, err := LoadOrCreateSecret(nil)
+	assert.Nil(t, err)
+	second, err := LoadOrCreateSecret(nil)
+	assert.Nil(t, err)
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+
+	store, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	assert.Nil(t, err)
+	defer store.Close()
+	first, err = LoadOrCreateSecret(store)
+	assert.Nil(t, err)
+	second, err = LoadOrCreateSecret(store)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+}
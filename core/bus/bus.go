@@ -2,6 +2,7 @@ package bus
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/sirupsen/logrus"
@@ -25,6 +26,11 @@ type Bus struct {
 	listeners map[string][]chan *BusEvent
 	// Indicates that the bus should be stopped
 	stop chan uint8
+	// Closed when the distribution stopped
+	done chan uint8
+	// Protects sending against a concurrent stop
+	mux     sync.RWMutex
+	stopped bool
 }
 
 // Inteface check
@@ -36,12 +42,13 @@ func NewBus() IBus {
 		main:      make(chan *BusEvent, 25),
 		listeners: make(map[string][]chan *BusEvent),
 		stop:      make(chan uint8, 1),
+		done:      make(chan uint8),
 	}
 	go bus.distribute()
 	return bus
 }
 
-func (b Bus) Listen(topic string) <-chan *BusEvent {
+func (b *Bus) Listen(topic string) <-chan *BusEvent {
 	ch := make(chan *BusEvent, 2)
 	arr, ok := b.listeners[strings.ToLower(topic)]
 	if ok {
@@ -52,13 +59,21 @@ func (b Bus) Listen(topic string) <-chan *BusEvent {
 	return ch
 }
 
-func (b Bus) Send(event *BusEvent) {
+func (b *Bus) Send(event *BusEvent) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	if b.stopped {
+		// Late events of connections being closed during shutdown
+		logrus.Warnf("Bus is stopped, event is dropped: %s", spew.Sdump(event))
+		return
+	}
 	logrus.Infof("New bus event: %s", spew.Sdump(event))
 	b.main <- event
 }
 
 // Used for incoming event distribution for listeners
 func (b *Bus) distribute() {
+	defer close(b.done)
 	for {
 		select {
 		case value := <-b.main:
@@ -72,18 +87,25 @@ func (b *Bus) distribute() {
 			}
 		case <-b.stop:
 			logrus.Infoln("Bus distribution is stopping!")
+			for _, channels := range b.listeners {
+				for _, ch := range channels {
+					close(ch)
+				}
+			}
 			return
 		}
 	}
 }
 
-func (b Bus) Stop() {
-	b.stop <- 1
-	close(b.main)
-	for _, channels := range b.listeners {
-		for _, ch := range channels {
-			close(ch)
-		}
+func (b *Bus) Stop() {
+	b.mux.Lock()
+	if b.stopped {
+		b.mux.Unlock()
+		return
 	}
+	b.stopped = true
+	b.mux.Unlock()
+	b.stop <- 1
+	<-b.done
 	logrus.Infoln("Bus is stopped!")
 }
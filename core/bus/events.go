@@ -1,6 +1,9 @@
 package bus
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Enums
 const EventTypeRegistration = "registration"
@@ -10,6 +13,13 @@ const EventTypeAttackFinished = "attack_finished"
 const EventTypeAttackInitiated = "attack_initiated"
 const EventTypeDefenseModuleInstalled = "defense_module_installed"
 const EventTypeDefenseFailed = "defense_failed"
+const EventTypeCheatAttempt = "cheat_attempt"
+const EventTypeCombatTimeout = "combat_timeout"
+const EventTypeGamePhaseChanged = "game_phase_changed"
+const EventTypeCountdown = "countdown"
+const EventTypeAdminAction = "admin_action"
+const EventTypeFirstBlood = "first_blood"
+const EventTypeAuditFailed = "audit_failed"
 
 // Describes a message sent to the bus
 type BusEvent struct {
@@ -83,6 +93,83 @@ func (be BusEvent) DecodeDefenseFailedEvent() (*DefenseFailedEvent, error) {
 	return nil, fmt.Errorf("Event is failed")
 }
 
+// Decodes a cheat attempt event
+func (be BusEvent) DecodeCheatAttemptEvent() (*CheatAttemptEvent, error) {
+	if be.Type != EventTypeCheatAttempt {
+		return nil, fmt.Errorf("Event is not cheat attempt")
+	}
+	if conv, ok := be.Information.(CheatAttemptEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not cheat attempt")
+}
+
+// Decodes a combat timeout event
+func (be BusEvent) DecodeCombatTimeoutEvent() (*CombatTimeoutEvent, error) {
+	if be.Type != EventTypeCombatTimeout {
+		return nil, fmt.Errorf("Event is not combat timeout")
+	}
+	if conv, ok := be.Information.(CombatTimeoutEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not combat timeout")
+}
+
+// Decodes a game phase changed event
+func (be BusEvent) DecodeGamePhaseChangedEvent() (*GamePhaseChangedEvent, error) {
+	if be.Type != EventTypeGamePhaseChanged {
+		return nil, fmt.Errorf("Event is not game phase changed")
+	}
+	if conv, ok := be.Information.(GamePhaseChangedEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not game phase changed")
+}
+
+// Decodes a countdown event
+func (be BusEvent) DecodeCountdownEvent() (*CountdownEvent, error) {
+	if be.Type != EventTypeCountdown {
+		return nil, fmt.Errorf("Event is not countdown")
+	}
+	if conv, ok := be.Information.(CountdownEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not countdown")
+}
+
+// Decodes an admin action event
+func (be BusEvent) DecodeAdminActionEvent() (*AdminActionEvent, error) {
+	if be.Type != EventTypeAdminAction {
+		return nil, fmt.Errorf("Event is not admin action")
+	}
+	if conv, ok := be.Information.(AdminActionEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not admin action")
+}
+
+// Decodes a first blood event
+func (be BusEvent) DecodeFirstBloodEvent() (*FirstBloodEvent, error) {
+	if be.Type != EventTypeFirstBlood {
+		return nil, fmt.Errorf("Event is not first blood")
+	}
+	if conv, ok := be.Information.(FirstBloodEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not first blood")
+}
+
+// Decodes an audit failed event
+func (be BusEvent) DecodeAuditFailedEvent() (*AuditFailedEvent, error) {
+	if be.Type != EventTypeAuditFailed {
+		return nil, fmt.Errorf("Event is not audit failed")
+	}
+	if conv, ok := be.Information.(AuditFailedEvent); ok {
+		return &conv, nil
+	}
+	return nil, fmt.Errorf("Event is not audit failed")
+}
+
 // Describes a registration event
 type RegistrationEvent struct {
 	Name string
@@ -120,3 +207,58 @@ type DefenseFailedEvent struct {
 	DefenderName string
 	AttackerName string
 }
+
+// Happens when a player sends data which differs
+// from what the server issued them
+type CheatAttemptEvent struct {
+	PlayerName    string
+	ChallengeName string
+	Reason        string
+}
+
+// Happens when a combat is closed because
+// one of the sides did not answer in time
+type CombatTimeoutEvent struct {
+	AttackerName  string
+	DefenderName  string
+	ChallengeName string
+	// Final state of the combat
+	State string
+}
+
+// Happens when the game moves into a new phase
+type GamePhaseChangedEvent struct {
+	Previous string
+	Phase    string
+}
+
+// Happens when the game reaches a countdown mark
+type CountdownEvent struct {
+	// Time left from the game
+	Remaining time.Duration
+}
+
+// Happens when a facilitator changes something
+// through the admin API
+type AdminActionEvent struct {
+	// Name of the action
+	Action string
+	// Human readable description of what happened
+	Description string
+}
+
+// Happens when an attacker is the first one
+// to solve a given challenge
+type FirstBloodEvent struct {
+	AttackerName  string
+	ChallengeName string
+}
+
+// Happens when a defender contradicts the example
+// of its own challenge in a determinism audit
+type AuditFailedEvent struct {
+	DefenderName  string
+	ChallengeName string
+	// Number of rejected solutions which were re-scored
+	RescoredCombats int
+}
@@ -9,6 +9,39 @@ const ChallengeTypeDefault = "default"
 // Describes any other challenges created by players
 const ChallengeTypePlayerCreated = "player_created"
 
+// Statuses of the challenges
+const (
+	// Challenge is installed but its creator did not pass the verification yet
+	ChallengeStatusPendingVerification = "pending_verification"
+	// Challenge is available for the attackers
+	ChallengeStatusPublished = "published"
+	// Challenge is pulled by the facilitator, it can be published again
+	ChallengeStatusDisabled = "disabled"
+	// Challenge is pulled by the facilitator for good
+	ChallengeStatusRetired = "retired"
+)
+
+// Returns true if a given status is one of the statuses
+func IsValidStatus(status string) bool {
+	switch status {
+	case ChallengeStatusPendingVerification, ChallengeStatusPublished, ChallengeStatusDisabled, ChallengeStatusRetired:
+		return true
+	}
+	return false
+}
+
+// Difficulty tiers of the challenges
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Returns true if a given difficulty is one of the tiers
+func IsValidDifficulty(difficulty string) bool {
+	return difficulty == DifficultyEasy || difficulty == DifficultyMedium || difficulty == DifficultyHard
+}
+
 // Describes a challenge
 type Model struct {
 	// ID of the challenge
@@ -21,10 +54,37 @@ type Model struct {
 	Description string
 	// Internal type (see consts for types)
 	Type string
+	// Difficulty declared by the creator (see consts for tiers)
+	Difficulty string
+	// Status of the challenge (see consts for statuses)
+	Status string
+	// Version of the challenge, increased by every update
+	// NOTE: The first version is 1
+	Version int
 	// Creation time
 	CreatedAt time.Time
+	// Time of the last update
+	UpdatedAt time.Time
 	// Example resolution
 	Example Example
+	// WebAssembly module serving the challenge instead of its creator
+	// NOTE: Empty if the creator serves the challenge over the websocket
+	Module []byte
+	// Describes if the creator serves the attacks while being online
+	// even though the challenge has a module
+	LiveEvaluation bool
+}
+
+// Returns true if the challenge is available for the attackers
+// NOTE: Challenges stored without a status predate the verification
+// and they count as published
+func (m Model) IsPublished() bool {
+	return m.Status == "" || m.Status == ChallengeStatusPublished
+}
+
+// Returns true if the challenge has a module which can serve it
+func (m Model) IsHosted() bool {
+	return len(m.Module) > 0
 }
 
 // Example of a challenge
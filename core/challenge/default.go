@@ -4,69 +4,76 @@ import (
 	"fmt"
 
 	"github.com/brianvoe/gofakeit/v6"
-	"github.com/google/uuid"
 )
 
-// First of the default modules
-var defaultModuleReverseSorter = Model{
-	ID:          uuid.NewString(),
-	Type:        ChallengeTypeDefault,
-	CreatorID:   "",
-	Name:        "Reverse sorter",
-	Description: "You receive a random length string array in the first parameter of the hints. Your aim is to change the order of the array and send it back as the first parameter of the solution array",
-	Example: Example{
-		Hints:     []interface{}{"123456"},
-		Solutions: []interface{}{"654321"},
-	},
-}
+// Pack loaded when no pack is chosen
+const DefaultPack = PackClassic
 
-// Returns the built in challenges
-func getDefaultChallenges() []Model {
-	return []Model{
-		defaultModuleReverseSorter,
+// Returns a registry containing the built in modules
+// NOTE: The classic pack only contains the reverse sorter,
+// the warmup pack contains every built in module
+func NewDefaultRegistry() IRegistry {
+	registry := NewRegistry()
+	modules := []IModule{
+		reverseSorter{},
+		caesarShift{},
+		fizzBuzzRange{},
+		jsonFieldSum{},
+		base64RoundTrip{},
+		matrixTranspose{},
+		primeFactorisation{},
+		anagramGrouping{},
+		vowelCounter{},
+		palindromeCheck{},
+		romanNumerals{},
+		runLengthEncoding{},
+		binaryConversion{},
 	}
+	for i, module := range modules {
+		packs := []string{PackWarmup}
+		if i == 0 {
+			packs = append(packs, PackClassic)
+		}
+		if err := registry.Register(module, packs...); err != nil {
+			// Built in modules are unique
+			panic(err)
+		}
+	}
+	return registry
 }
 
-// Returns hints for a default module
-func getHintsForDefaultModule(m Model) ([]interface{}, error) {
-	if m.Type != ChallengeTypeDefault {
-		return nil, fmt.Errorf("Default module error: %s (%s) is %s", m.ID, m.Name, m.Type)
-	}
-	gofakeit.Seed(0)
-	if m.Name == defaultModuleReverseSorter.Name {
-		hint := fmt.Sprintf("%s%s%s", gofakeit.Word(), gofakeit.HipsterWord(), gofakeit.BuzzWord())
-		return []interface{}{hint}, nil
+// First of the default modules
+type reverseSorter struct{}
+
+// Interface check
+var _ IModule = (*reverseSorter)(nil)
+
+func (reverseSorter) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "Reverse sorter",
+		Description: "You receive a random length string array in the first parameter of the hints. Your aim is to change the order of the array and send it back as the first parameter of the solution array",
+		Example: Example{
+			Hints:     []interface{}{"123456"},
+			Solutions: []interface{}{"654321"},
+		},
 	}
-	return nil, fmt.Errorf("Default module not found: %s (%s) is %s", m.ID, m.Name, m.Type)
 }
 
-// Validates a given solution to a given default module
-func isValidDefaultModuleSolution(m Model, hints []interface{}, solutions []interface{}) (bool, error) {
-	if m.Type != ChallengeTypeDefault {
-		return false, fmt.Errorf("Default module error: %s (%s) is %s", m.ID, m.Name, m.Type)
+func (reverseSorter) GenerateHints(random *gofakeit.Faker) []interface{} {
+	return []interface{}{fmt.Sprintf("%s%s%s", random.Word(), random.HipsterWord(), random.BuzzWord())}
+}
+
+func (reverseSorter) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	solution, err := getString(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
 	}
-	if m.Name == defaultModuleReverseSorter.Name {
-		if len(solutions) == 0 {
-			return false, fmt.Errorf("Solutions needs to be at least 1 long")
-		}
-		if len(hints) == 0 {
-			return false, fmt.Errorf("Hints needs to be at least 1 long")
-		}
-		switch solutions[0].(type) {
-		case string:
-			solution := solutions[0].(string)
-			switch hints[0].(type) {
-			case string:
-				hint := hints[0].(string)
-				return isValidReverseSorterSolution(hint, solution), nil
-			default:
-				return false, fmt.Errorf("Hint has to be string")
-			}
-		default:
-			return false, fmt.Errorf("Solution has to be string")
-		}
+	hint, err := getString(hints, 0, "Hints")
+	if err != nil {
+		return false, err
 	}
-	return false, fmt.Errorf("Module is not a known module")
+	return isValidReverseSorterSolution(hint, solution), nil
 }
 
 // Validates the solution directly
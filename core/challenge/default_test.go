@@ -3,6 +3,7 @@ package challenge
 import (
 	"testing"
 
+	"github.com/brianvoe/gofakeit/v6"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,3 +14,83 @@ func TestDefaultReverseSorter(t *testing.T) {
 	assert.False(t, isValidReverseSorterSolution("YoUr_BoY_goT_swAAG", "gaaws_tog_yob_ruoy"))
 	assert.True(t, isValidReverseSorterSolution("YoUr_BoY_goT_swAAG", "GAAws_Tog_YoB_rUoY"))
 }
+
+func TestDefaultModuleExamples(t *testing.T) {
+	modules, err := NewDefaultRegistry().GetPack(PackWarmup)
+	assert.Nil(t, err)
+	assert.Equal(t, 13, len(modules))
+	for _, module := range modules {
+		example := module.GetChallenge().Example
+		hints, err := normalize(example.Hints)
+		assert.Nil(t, err)
+		solutions, err := normalize(example.Solutions)
+		assert.Nil(t, err)
+		isValid, err := module.Validate(hints, solutions)
+		assert.Nil(t, err, module.GetChallenge().Name)
+		assert.True(t, isValid, module.GetChallenge().Name)
+
+		isValid, _ = module.Validate(hints, []interface{}{"wrong"})
+		assert.False(t, isValid, module.GetChallenge().Name)
+		isValid, _ = module.Validate(hints, []interface{}{})
+		assert.False(t, isValid, module.GetChallenge().Name)
+
+		// Generated hints have the shape the validator expects
+		generated, err := normalize(module.GenerateHints(gofakeit.New(0)))
+		assert.Nil(t, err)
+		isValid, _ = module.Validate(generated, []interface{}{"wrong"})
+		assert.False(t, isValid, module.GetChallenge().Name)
+	}
+}
+
+func TestDefaultModuleSolutions(t *testing.T) {
+	isValid, err := anagramGrouping{}.Validate(
+		[]interface{}{"tea", "ate", "bat", "eat"},
+		[]interface{}{[]interface{}{"bat"}, []interface{}{"eat", "tea", "ate"}})
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+	isValid, err = anagramGrouping{}.Validate(
+		[]interface{}{"tea", "ate", "bat", "eat"},
+		[]interface{}{[]interface{}{"bat", "tea"}, []interface{}{"eat", "ate"}})
+	assert.Nil(t, err)
+	assert.False(t, isValid)
+
+	isValid, err = caesarShift{}.Validate([]interface{}{"xyz", float64(2)}, []interface{}{"zab"})
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+	_, err = caesarShift{}.Validate([]interface{}{"xyz", 2.5}, []interface{}{"zab"})
+	assert.NotNil(t, err)
+
+	isValid, err = base64RoundTrip{}.Validate([]interface{}{"decode", "Y2VudHVyaW9u"}, []interface{}{"centurion"})
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+
+	isValid, err = primeFactorisation{}.Validate([]interface{}{float64(97)}, []interface{}{float64(97)})
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+	isValid, err = primeFactorisation{}.Validate([]interface{}{float64(60)}, []interface{}{float64(2), float64(3), float64(2), float64(5)})
+	assert.Nil(t, err)
+	assert.False(t, isValid)
+
+	isValid, err = romanNumerals{}.Validate([]interface{}{float64(1994)}, []interface{}{"MCMXCIV"})
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	assert.Nil(t, registry.Register(reverseSorter{}, PackClassic))
+	assert.NotNil(t, registry.Register(reverseSorter{}, PackWarmup))
+	assert.NotNil(t, registry.Register(caesarShift{}, PackNone))
+
+	module, err := registry.Find("REVERSE SORTER")
+	assert.Nil(t, err)
+	assert.Equal(t, "Reverse sorter", module.GetChallenge().Name)
+	_, err = registry.Find("Caesar shift")
+	assert.NotNil(t, err)
+
+	modules, err := registry.GetPack(PackNone)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(modules))
+	_, err = registry.GetPack(PackWarmup)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,170 @@
+package challenge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// Enums
+const PackClassic = "classic"
+const PackWarmup = "warmup"
+const PackNone = "none"
+
+// Describes a default module defended by the system
+type IModule interface {
+	// Returns the challenge of the module
+	// NOTE: ID, type, status and creator are set when the module is installed
+	GetChallenge() Model
+	// Generates hints for a new attack using a given random source
+	GenerateHints(random *gofakeit.Faker) []interface{}
+	// Validates given solutions to given hints
+	// NOTE: Both are in JSON decoded form (string, float64, bool, []interface{} or map[string]interface{})
+	Validate(hints []interface{}, solutions []interface{}) (bool, error)
+}
+
+// Describes a registry of default modules
+type IRegistry interface {
+	// Registers a module in the given packs
+	// NOTE: Module names are unique (case insensitive)
+	Register(module IModule, packs ...string) error
+	// Finds a module by the name of its challenge
+	Find(name string) (IModule, error)
+	// Returns the modules of a given pack in the order of registration
+	GetPack(pack string) ([]IModule, error)
+}
+
+type Registry struct {
+	modules map[string]IModule
+	packs   map[string][]IModule
+}
+
+// Interface check
+var _ IRegistry = (*Registry)(nil)
+
+// Returns an empty registry
+// NOTE: The "none" pack is always available
+func NewRegistry() IRegistry {
+	return &Registry{
+		modules: map[string]IModule{},
+		packs: map[string][]IModule{
+			PackNone: {},
+		},
+	}
+}
+
+func (r *Registry) Register(module IModule, packs ...string) error {
+	name := strings.ToLower(module.GetChallenge().Name)
+	if _, ok := r.modules[name]; ok {
+		return fmt.Errorf("%s module is already registered", module.GetChallenge().Name)
+	}
+	for _, pack := range packs {
+		if pack == PackNone {
+			return fmt.Errorf("Modules cannot be registered in the %s pack", PackNone)
+		}
+	}
+	r.modules[name] = module
+	for _, pack := range packs {
+		r.packs[pack] = append(r.packs[pack], module)
+	}
+	return nil
+}
+
+func (r Registry) Find(name string) (IModule, error) {
+	if module, ok := r.modules[strings.ToLower(name)]; ok {
+		return module, nil
+	}
+	return nil, fmt.Errorf("Default module not found: %s", name)
+}
+
+func (r Registry) GetPack(pack string) ([]IModule, error) {
+	modules, ok := r.packs[pack]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a known module pack", pack)
+	}
+	return append([]IModule{}, modules...), nil
+}
+
+// Converts values into the form they have after being sent through the API
+func normalize(values []interface{}) ([]interface{}, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	normalized := []interface{}{}
+	if err = json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// Returns true if the JSON form of the given values is the same
+func isSameJSON(a interface{}, b interface{}) bool {
+	encodedA, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	encodedB, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(encodedA) == string(encodedB)
+}
+
+// Returns the value at a given index of the hints or solutions
+func getValue(values []interface{}, index int, name string) (interface{}, error) {
+	if len(values) <= index {
+		return nil, fmt.Errorf("%s needs to be at least %d long", name, index+1)
+	}
+	return values[index], nil
+}
+
+// Returns the string at a given index of the hints or solutions
+func getString(values []interface{}, index int, name string) (string, error) {
+	value, err := getValue(values, index, name)
+	if err != nil {
+		return "", err
+	}
+	if converted, ok := value.(string); ok {
+		return converted, nil
+	}
+	return "", fmt.Errorf("Element %d of %s has to be string", index+1, strings.ToLower(name))
+}
+
+// Returns the number at a given index of the hints or solutions
+func getNumber(values []interface{}, index int, name string) (float64, error) {
+	value, err := getValue(values, index, name)
+	if err != nil {
+		return 0, err
+	}
+	if converted, ok := value.(float64); ok {
+		return converted, nil
+	}
+	return 0, fmt.Errorf("Element %d of %s has to be number", index+1, strings.ToLower(name))
+}
+
+// Returns the integer at a given index of the hints or solutions
+func getInteger(values []interface{}, index int, name string) (int, error) {
+	value, err := getNumber(values, index, name)
+	if err != nil {
+		return 0, err
+	}
+	if value != float64(int(value)) {
+		return 0, fmt.Errorf("Element %d of %s has to be integer", index+1, strings.ToLower(name))
+	}
+	return int(value), nil
+}
+
+// Returns the array at a given index of the hints or solutions
+func getArray(values []interface{}, index int, name string) ([]interface{}, error) {
+	value, err := getValue(values, index, name)
+	if err != nil {
+		return nil, err
+	}
+	if converted, ok := value.([]interface{}); ok {
+		return converted, nil
+	}
+	return nil, fmt.Errorf("Element %d of %s has to be array", index+1, strings.ToLower(name))
+}
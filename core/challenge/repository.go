@@ -5,20 +5,59 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/riltech/centurion/core/storage"
 )
 
+// Bucket of the challenges in the store
+const storeBucket = "challenges"
+
+// Bucket of the previous versions of the challenges in the store
+const revisionsBucket = "challenge_revisions"
+
+// Bucket of the versions waiting for verification in the store
+const draftsBucket = "challenge_drafts"
+
 // Describes a repository for challenges
 type IRepository interface {
 	// Fetches the available challenges in the system
 	GetChallenges() []Model
 	// Adds a new challenge
 	AddChallenge(Model) error
+	// Removes a challenge by ID
+	RemoveChallenge(ID string) error
+	// Updates the status of a challenge by ID
+	UpdateStatus(ID string, status string) (Model, error)
+	// Replaces a challenge with a given new version
+	// NOTE: The previous version is kept as a revision
+	UpdateChallenge(Model) (Model, error)
+	// Stores a new version of a challenge which waits for verification
+	// NOTE: The current version is kept until the draft is promoted
+	AddDraft(Model) (Model, error)
+	// Replaces a challenge with its draft by ID
+	// NOTE: The status of the current version is kept
+	PromoteDraft(ID string) (Model, error)
+	// Removes the draft of a challenge by ID
+	RemoveDraft(ID string) error
+	// Fetches the versions waiting for verification
+	GetDrafts() []Model
+	// Fetches the previous versions of a challenge by ID
+	GetRevisions(ID string) []Model
 }
 
 // Challenge repository implementation
 type Repository struct {
 	mux        sync.RWMutex
 	challenges []Model
+	// Previous versions of the challenges
+	revisions []Model
+	// Versions of the challenges waiting for verification
+	drafts []Model
+	// Optional persistence, nil when running in memory only
+	store            storage.IStore
+	storedChallenges *storage.Collection
+	storedRevisions  *storage.Collection
+	storedDrafts     *storage.Collection
 }
 
 // Interface check
@@ -31,23 +70,231 @@ func (r *Repository) AddChallenge(challenge Model) error {
 	r.mux.Lock()
 	defer r.mux.Unlock()
 	challenge.CreatedAt = time.Now()
-	if r.challenges == nil {
-		r.challenges = []Model{challenge}
-		return nil
-	}
+	challenge.UpdatedAt = challenge.CreatedAt
+	challenge.Version = 1
 	for _, c := range r.challenges {
 		if c.ID == challenge.ID || strings.ToLower(c.Name) == strings.ToLower(challenge.Name) {
 			return fmt.Errorf("Cannot use the same id (expected, got) (%s, %s) or challenge title (%s, %s)", challenge.ID, c.ID, challenge.Name, c.Name)
 		}
 	}
 	r.challenges = append(r.challenges, challenge)
-	return nil
+	return r.persist(func(tx storage.ITx) error {
+		return r.storedChallenges.Put(tx, challenge.ID, challenge)
+	})
+}
+
+func (r *Repository) RemoveChallenge(ID string) error {
+	if r == nil {
+		return fmt.Errorf("Repository needs to be initialised before usage")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i, c := range r.challenges {
+		if c.ID == ID {
+			r.challenges = append(r.challenges[:i], r.challenges[i+1:]...)
+			r.removeDraft(ID)
+			return r.persist(func(tx storage.ITx) error {
+				if err := r.storedChallenges.Delete(tx, ID); err != nil {
+					return err
+				}
+				return r.storedDrafts.Delete(tx, ID)
+			})
+		}
+	}
+	return fmt.Errorf("%s challenge is not found", ID)
+}
+
+func (r *Repository) UpdateStatus(ID string, status string) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository needs to be initialised before usage")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i := range r.challenges {
+		if r.challenges[i].ID == ID {
+			r.challenges[i].Status = status
+			return r.challenges[i], r.persist(func(tx storage.ITx) error {
+				return r.storedChallenges.Put(tx, ID, r.challenges[i])
+			})
+		}
+	}
+	return Model{}, fmt.Errorf("%s challenge is not found", ID)
+}
+
+func (r *Repository) UpdateChallenge(challenge Model) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository needs to be initialised before usage")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.findDraft(challenge.ID) >= 0 {
+		return Model{}, fmt.Errorf("An update of %s challenge is waiting for verification", challenge.ID)
+	}
+	for i, c := range r.challenges {
+		if c.ID != challenge.ID {
+			continue
+		}
+		r.revisions = append(r.revisions, c)
+		// Identity of the challenge cannot change
+		challenge.CreatorID = c.CreatorID
+		challenge.Type = c.Type
+		challenge.CreatedAt = c.CreatedAt
+		challenge.UpdatedAt = time.Now()
+		challenge.Version = c.Version + 1
+		r.challenges[i] = challenge
+		return challenge, r.persist(func(tx storage.ITx) error {
+			if err := r.storedRevisions.Put(tx, revisionID(c), c); err != nil {
+				return err
+			}
+			return r.storedChallenges.Put(tx, challenge.ID, challenge)
+		})
+	}
+	return Model{}, fmt.Errorf("%s challenge is not found", challenge.ID)
+}
+
+func (r *Repository) AddDraft(challenge Model) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository needs to be initialised before usage")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.findDraft(challenge.ID) >= 0 {
+		return Model{}, fmt.Errorf("An update of %s challenge is waiting for verification", challenge.ID)
+	}
+	for _, c := range r.challenges {
+		if c.ID != challenge.ID {
+			continue
+		}
+		// Identity of the challenge cannot change
+		challenge.CreatorID = c.CreatorID
+		challenge.Type = c.Type
+		challenge.CreatedAt = c.CreatedAt
+		challenge.UpdatedAt = time.Now()
+		challenge.Version = c.Version + 1
+		challenge.Status = ChallengeStatusPendingVerification
+		r.drafts = append(r.drafts, challenge)
+		return challenge, r.persist(func(tx storage.ITx) error {
+			return r.storedDrafts.Put(tx, challenge.ID, challenge)
+		})
+	}
+	return Model{}, fmt.Errorf("%s challenge is not found", challenge.ID)
+}
+
+func (r *Repository) PromoteDraft(ID string) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository needs to be initialised before usage")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	j := r.findDraft(ID)
+	if j < 0 {
+		return Model{}, fmt.Errorf("%s challenge has no update waiting for verification", ID)
+	}
+	for i, c := range r.challenges {
+		if c.ID != ID {
+			continue
+		}
+		draft := r.drafts[j]
+		// The facilitator might have changed the status meanwhile
+		draft.Status = c.Status
+		r.revisions = append(r.revisions, c)
+		r.challenges[i] = draft
+		r.drafts = append(r.drafts[:j], r.drafts[j+1:]...)
+		return draft, r.persist(func(tx storage.ITx) error {
+			if err := r.storedRevisions.Put(tx, revisionID(c), c); err != nil {
+				return err
+			}
+			if err := r.storedChallenges.Put(tx, ID, draft); err != nil {
+				return err
+			}
+			return r.storedDrafts.Delete(tx, ID)
+		})
+	}
+	return Model{}, fmt.Errorf("%s challenge is not found", ID)
+}
+
+func (r *Repository) RemoveDraft(ID string) error {
+	if r == nil {
+		return fmt.Errorf("Repository needs to be initialised before usage")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if !r.removeDraft(ID) {
+		return fmt.Errorf("%s challenge has no update waiting for verification", ID)
+	}
+	return r.persist(func(tx storage.ITx) error {
+		return r.storedDrafts.Delete(tx, ID)
+	})
+}
+
+func (r *Repository) GetDrafts() []Model {
+	defer r.mux.RUnlock()
+	r.mux.RLock()
+	drafts := make([]Model, len(r.drafts))
+	copy(drafts, r.drafts)
+	return drafts
+}
+
+// Returns the index of the draft of a given challenge or -1 if there is none
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) findDraft(ID string) int {
+	for i, c := range r.drafts {
+		if c.ID == ID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Removes the draft of a given challenge and returns true if there was one
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) removeDraft(ID string) bool {
+	i := r.findDraft(ID)
+	if i < 0 {
+		return false
+	}
+	r.drafts = append(r.drafts[:i], r.drafts[i+1:]...)
+	return true
+}
+
+func (r *Repository) GetRevisions(ID string) []Model {
+	defer r.mux.RUnlock()
+	r.mux.RLock()
+	revisions := []Model{}
+	for _, c := range r.revisions {
+		if c.ID == ID {
+			revisions = append(revisions, c)
+		}
+	}
+	return revisions
 }
 
 func (r *Repository) GetChallenges() []Model {
 	defer r.mux.RUnlock()
 	r.mux.RLock()
-	return r.challenges
+	// Challenges can be removed in place
+	// so a copy is returned to avoid races
+	challenges := make([]Model, len(r.challenges))
+	copy(challenges, r.challenges)
+	return challenges
+}
+
+// Writes the changes of a given function into the store in a single transaction
+// if persistence is enabled
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) persist(fn func(tx storage.ITx) error) error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Update(fn)
+}
+
+// Returns the ID a given version of a challenge is stored by
+func revisionID(m Model) string {
+	return fmt.Sprintf("%s/%d", m.ID, m.Version)
 }
 
 // Constructor to create a new engine repository
@@ -56,3 +303,39 @@ func NewRepository() *Repository {
 		mux: sync.RWMutex{},
 	}
 }
+
+// Constructor to create a repository backed by a store
+func NewPersistentRepository(store storage.IStore) (*Repository, error) {
+	r := &Repository{
+		mux:              sync.RWMutex{},
+		store:            store,
+		storedChallenges: storage.NewCollection(storeBucket),
+		storedRevisions:  storage.NewCollection(revisionsBucket),
+		storedDrafts:     storage.NewCollection(draftsBucket),
+	}
+	if err := r.storedChallenges.Load(store, func(load func(interface{}) error) (string, error) {
+		var c Model
+		err := load(&c)
+		r.challenges = append(r.challenges, c)
+		return c.ID, err
+	}); err != nil {
+		return nil, err
+	}
+	if err := r.storedRevisions.Load(store, func(load func(interface{}) error) (string, error) {
+		var c Model
+		err := load(&c)
+		r.revisions = append(r.revisions, c)
+		return revisionID(c), err
+	}); err != nil {
+		return nil, err
+	}
+	if err := r.storedDrafts.Load(store, func(load func(interface{}) error) (string, error) {
+		var c Model
+		err := load(&c)
+		r.drafts = append(r.drafts, c)
+		return c.ID, err
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
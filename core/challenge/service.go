@@ -2,23 +2,53 @@ package challenge
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
 )
 
 // Describes a player service interface
 type IService interface {
-	// Used for adding the default defender modules in the beginning of the game
+	// Used for adding the default defender modules of the chosen pack in the beginning of the game
+	// NOTE: Modules which are already stored are skipped
 	AddDefaultModules() error
 	// Adds a new challenge to the system
 	AddChallenge(Model) error
+	// Removes a challenge from the system
+	RemoveChallenge(ID string) error
 	// For fetching available challenges
+	// NOTE: Challenges waiting for verification are not included
 	GetChallenges() []Model
+	// Returns the challenges (and the updates) of a given creator waiting for verification
+	GetPendingChallenges(creatorID string) []Model
+	// Updates the status of a given challenge
+	// Use the status enums from the package
+	// NOTE: Retired challenges cannot be changed anymore
+	SetStatus(ID string, status string) (Model, error)
+	// Stores a new version of a given challenge
+	UpdateChallenge(Model) (Model, error)
+	// Stores a new version of a given challenge as a draft which waits for verification
+	// NOTE: Attackers keep getting the current version until the draft is published
+	DraftChallenge(Model) (Model, error)
+	// Finds the draft of a given challenge
+	FindDraft(ID string) (Model, error)
+	// Replaces a given challenge with its verified draft
+	PublishDraft(ID string) (Model, error)
+	// Drops the draft of a given challenge which failed the verification
+	DiscardDraft(ID string) error
 	// Finds a given challenge by ID
 	FindByID(ID string) (Model, error)
+	// Finds a given version of a challenge (including its draft)
+	// NOTE: Version 0 refers to the current version
+	FindVersion(ID string, version int) (Model, error)
 	// Generates hint for a default challenge
 	GenerateHintForDefault(Model) ([]interface{}, error)
 	// Validates a given solution for a default module
 	IsValidSolutionToDefaultModule(m Model, hints []interface{}, solutions []interface{}) (bool, error)
 	// Returns if this is the first module of the defender
+	// NOTE: Every other challenge the defender published counts,
+	// even if it was disabled or retired since
 	IsFirstModule(Model) bool
 	// Returns the number of unique challenges
 	GetNumberOfUniqueChallenges() int
@@ -26,17 +56,93 @@ type IService interface {
 
 type Service struct {
 	repository IRepository
+	registry   IRegistry
+	// Name of the default module pack to install
+	pack string
+	// Random source of the hints generated for default modules
+	random *gofakeit.Faker
 }
 
 // Interface check
 var _ IService = (*Service)(nil)
 
+// Returns a service installing the default pack of the built in modules
+// NOTE: Hints are generated from a randomly seeded source
 func NewService(repository IRepository) IService {
-	return &Service{repository}
+	return &Service{repository, NewDefaultRegistry(), DefaultPack, gofakeit.New(0)}
+}
+
+// Returns a service installing a given pack of a given registry
+// Hints of the default modules are generated from the given random source
+func NewServiceWithModules(repository IRepository, registry IRegistry, pack string, random *gofakeit.Faker) (IService, error) {
+	if _, err := registry.GetPack(pack); err != nil {
+		return nil, err
+	}
+	return &Service{repository, registry, pack, random}, nil
 }
 
 func (s Service) GetChallenges() []Model {
-	return s.repository.GetChallenges()
+	published := []Model{}
+	for _, c := range s.repository.GetChallenges() {
+		if c.IsPublished() {
+			published = append(published, c)
+		}
+	}
+	return published
+}
+
+func (s Service) GetPendingChallenges(creatorID string) []Model {
+	pending := []Model{}
+	for _, c := range s.repository.GetChallenges() {
+		if c.CreatorID == creatorID && c.Status == ChallengeStatusPendingVerification {
+			pending = append(pending, c)
+		}
+	}
+	for _, c := range s.repository.GetDrafts() {
+		if c.CreatorID == creatorID {
+			pending = append(pending, c)
+		}
+	}
+	return pending
+}
+
+func (s Service) SetStatus(ID string, status string) (Model, error) {
+	if !IsValidStatus(status) {
+		return Model{}, fmt.Errorf("%s is not a valid challenge status", status)
+	}
+	current, err := s.FindByID(ID)
+	if err != nil {
+		return Model{}, err
+	}
+	if current.Status == ChallengeStatusRetired {
+		return Model{}, fmt.Errorf("%s challenge is retired", ID)
+	}
+	return s.repository.UpdateStatus(ID, status)
+}
+
+func (s Service) UpdateChallenge(m Model) (Model, error) {
+	return s.repository.UpdateChallenge(m)
+}
+
+func (s Service) DraftChallenge(m Model) (Model, error) {
+	return s.repository.AddDraft(m)
+}
+
+func (s Service) FindDraft(ID string) (Model, error) {
+	for _, draft := range s.repository.GetDrafts() {
+		if draft.ID == ID {
+			return draft, nil
+		}
+	}
+	return Model{}, fmt.Errorf("%s challenge has no update waiting for verification", ID)
+}
+
+func (s Service) PublishDraft(ID string) (Model, error) {
+	return s.repository.PromoteDraft(ID)
+}
+
+func (s Service) DiscardDraft(ID string) error {
+	return s.repository.RemoveDraft(ID)
 }
 
 func (s Service) FindByID(ID string) (m Model, e error) {
@@ -50,12 +156,51 @@ func (s Service) FindByID(ID string) (m Model, e error) {
 	return
 }
 
+func (s Service) FindVersion(ID string, version int) (Model, error) {
+	current, err := s.FindByID(ID)
+	if err == nil && (version == 0 || current.Version == version) {
+		return current, nil
+	}
+	for _, revision := range s.repository.GetRevisions(ID) {
+		if revision.Version == version {
+			return revision, nil
+		}
+	}
+	if draft, err := s.FindDraft(ID); err == nil && draft.Version == version {
+		return draft, nil
+	}
+	return Model{}, fmt.Errorf("Version %d of %s challenge is not found", version, ID)
+}
+
 func (s Service) AddChallenge(m Model) error {
 	return s.repository.AddChallenge(m)
 }
 
+func (s Service) RemoveChallenge(ID string) error {
+	return s.repository.RemoveChallenge(ID)
+}
+
 func (s Service) AddDefaultModules() error {
-	for _, challenge := range getDefaultChallenges() {
+	installed := map[string]bool{}
+	for _, c := range s.repository.GetChallenges() {
+		if c.Type == ChallengeTypeDefault {
+			installed[strings.ToLower(c.Name)] = true
+		}
+	}
+	modules, err := s.registry.GetPack(s.pack)
+	if err != nil {
+		return err
+	}
+	for _, module := range modules {
+		challenge := module.GetChallenge()
+		// Modules restored from a previous run are kept with their original ID
+		if installed[strings.ToLower(challenge.Name)] {
+			continue
+		}
+		challenge.ID = uuid.NewString()
+		challenge.Type = ChallengeTypeDefault
+		challenge.Status = ChallengeStatusPublished
+		challenge.CreatorID = ""
 		if err := s.repository.AddChallenge(challenge); err != nil {
 			return err
 		}
@@ -64,17 +209,42 @@ func (s Service) AddDefaultModules() error {
 }
 
 func (s Service) GenerateHintForDefault(m Model) ([]interface{}, error) {
-	return getHintsForDefaultModule(m)
+	module, err := s.findModule(m)
+	if err != nil {
+		return nil, err
+	}
+	return module.GenerateHints(s.random), nil
 }
 
 func (s Service) IsValidSolutionToDefaultModule(m Model, hints []interface{}, solutions []interface{}) (bool, error) {
-	return isValidDefaultModuleSolution(m, hints, solutions)
+	module, err := s.findModule(m)
+	if err != nil {
+		return false, err
+	}
+	// Hints stored in memory keep their original types
+	hints, err = normalize(hints)
+	if err != nil {
+		return false, err
+	}
+	solutions, err = normalize(solutions)
+	if err != nil {
+		return false, err
+	}
+	return module.Validate(hints, solutions)
+}
+
+// Returns the registered module of a given default challenge
+func (s Service) findModule(m Model) (IModule, error) {
+	if m.Type != ChallengeTypeDefault {
+		return nil, fmt.Errorf("Default module error: %s (%s) is %s", m.ID, m.Name, m.Type)
+	}
+	return s.registry.Find(m.Name)
 }
 
 func (s Service) IsFirstModule(m Model) bool {
 	numberOfModules := 0
 	for _, c := range s.repository.GetChallenges() {
-		if c.CreatorID == m.CreatorID {
+		if c.CreatorID == m.CreatorID && c.ID != m.ID && c.Status != ChallengeStatusPendingVerification {
 			numberOfModules++
 		}
 	}
@@ -83,7 +253,7 @@ func (s Service) IsFirstModule(m Model) bool {
 
 func (s Service) GetNumberOfUniqueChallenges() int {
 	unique := map[string]uint8{}
-	for _, c := range s.repository.GetChallenges() {
+	for _, c := range s.GetChallenges() {
 		unique[c.ID] = 1
 	}
 	return len(unique)
@@ -0,0 +1,199 @@
+package challenge
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/riltech/centurion/core/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddDefaultModulesAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	repo, err := NewPersistentRepository(store)
+	assert.Nil(t, err)
+	assert.Nil(t, NewService(repo).AddDefaultModules())
+	before := repo.GetChallenges()
+	assert.Nil(t, store.Close())
+
+	store, err = storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	repo, err = NewPersistentRepository(store)
+	assert.Nil(t, err)
+	assert.Nil(t, NewService(repo).AddDefaultModules())
+	after := repo.GetChallenges()
+	assert.Equal(t, len(before), len(after))
+	assert.Equal(t, before[0].ID, after[0].ID)
+}
+
+func TestVersions(t *testing.T) {
+	service := NewService(NewRepository())
+	assert.Nil(t, service.AddChallenge(Model{ID: "a", Name: "Reverse", Description: "Typo", Status: ChallengeStatusPublished}))
+	first, err := service.FindByID("a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, first.Version)
+
+	update := first
+	update.Description = "Reverse the hint"
+	updated, err := service.UpdateChallenge(update)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, first.CreatedAt, updated.CreatedAt)
+
+	// Past versions stay available
+	found, err := service.FindVersion("a", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Typo", found.Description)
+	found, err = service.FindVersion("a", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Reverse the hint", found.Description)
+	_, err = service.FindVersion("a", 3)
+	assert.NotNil(t, err)
+
+	// Drafts do not replace the current version until they are published
+	draft := updated
+	draft.Description = "Reverse the hints"
+	draft.Status = ChallengeStatusPublished
+	drafted, err := service.DraftChallenge(draft)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, drafted.Version)
+	assert.Equal(t, ChallengeStatusPendingVerification, drafted.Status)
+	_, err = service.DraftChallenge(draft)
+	assert.NotNil(t, err)
+	_, err = service.UpdateChallenge(draft)
+	assert.NotNil(t, err)
+	current, _ := service.FindByID("a")
+	assert.Equal(t, updated, current)
+	found, err = service.FindVersion("a", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, drafted, found)
+	assert.Len(t, service.GetPendingChallenges(""), 1)
+
+	// Discarded drafts are gone for good
+	assert.Nil(t, service.DiscardDraft("a"))
+	assert.NotNil(t, service.DiscardDraft("a"))
+	_, err = service.FindVersion("a", 3)
+	assert.NotNil(t, err)
+
+	// Published drafts become the current version
+	_, err = service.DraftChallenge(draft)
+	assert.Nil(t, err)
+	published, err := service.PublishDraft("a")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, published.Version)
+	assert.Equal(t, ChallengeStatusPublished, published.Status)
+	assert.Equal(t, "Reverse the hints", published.Description)
+	found, err = service.FindVersion("a", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, updated, found)
+	_, err = service.FindDraft("a")
+	assert.NotNil(t, err)
+
+	// Retired challenges are hidden for good
+	_, err = service.SetStatus("a", ChallengeStatusDisabled)
+	assert.Nil(t, err)
+	assert.Empty(t, service.GetChallenges())
+	_, err = service.SetStatus("a", ChallengeStatusRetired)
+	assert.Nil(t, err)
+	_, err = service.SetStatus("a", ChallengeStatusPublished)
+	assert.NotNil(t, err)
+	_, err = service.SetStatus("a", "unknown")
+	assert.NotNil(t, err)
+}
+
+func TestIsFirstModule(t *testing.T) {
+	service := NewService(NewRepository())
+	first := Model{ID: "a", Name: "First", CreatorID: "xxx", Status: ChallengeStatusPendingVerification}
+	second := Model{ID: "b", Name: "Second", CreatorID: "xxx", Status: ChallengeStatusPendingVerification}
+	assert.Nil(t, service.AddChallenge(first))
+	assert.Nil(t, service.AddChallenge(second))
+	// Pending challenges do not count
+	assert.True(t, service.IsFirstModule(first))
+	assert.True(t, service.IsFirstModule(second))
+
+	_, err := service.SetStatus(first.ID, ChallengeStatusPublished)
+	assert.Nil(t, err)
+	assert.False(t, service.IsFirstModule(second))
+	// Published challenges count after being retired
+	_, err = service.SetStatus(first.ID, ChallengeStatusRetired)
+	assert.Nil(t, err)
+	assert.False(t, service.IsFirstModule(second))
+	assert.True(t, service.IsFirstModule(Model{ID: "c", CreatorID: "yyy"}))
+}
+
+func TestDefaultPacks(t *testing.T) {
+	_, err := NewServiceWithModules(NewRepository(), NewDefaultRegistry(), "unknown", gofakeit.New(0))
+	assert.NotNil(t, err)
+
+	service, err := NewServiceWithModules(NewRepository(), NewDefaultRegistry(), PackNone, gofakeit.New(0))
+	assert.Nil(t, err)
+	assert.Nil(t, service.AddDefaultModules())
+	assert.Equal(t, 0, len(service.GetChallenges()))
+
+	service, err = NewServiceWithModules(NewRepository(), NewDefaultRegistry(), PackClassic, gofakeit.New(0))
+	assert.Nil(t, err)
+	assert.Nil(t, service.AddDefaultModules())
+	assert.Equal(t, 1, len(service.GetChallenges()))
+	target := service.GetChallenges()[0]
+	assert.Equal(t, ChallengeTypeDefault, target.Type)
+	assert.True(t, target.IsPublished())
+
+	// The classic pack is installed by default
+	service = NewService(NewRepository())
+	assert.Nil(t, service.AddDefaultModules())
+	assert.Equal(t, 1, len(service.GetChallenges()))
+
+	service, err = NewServiceWithModules(NewRepository(), NewDefaultRegistry(), PackWarmup, gofakeit.New(0))
+	assert.Nil(t, err)
+	assert.Nil(t, service.AddDefaultModules())
+	assert.Equal(t, 13, len(service.GetChallenges()))
+	// Hints kept in memory are validated the same way as decoded ones
+	for _, c := range service.GetChallenges() {
+		_, err = service.GenerateHintForDefault(c)
+		assert.Nil(t, err)
+		isValid, err := service.IsValidSolutionToDefaultModule(c, c.Example.Hints, c.Example.Solutions)
+		assert.Nil(t, err)
+		assert.True(t, isValid, c.Name)
+	}
+	_, err = service.GenerateHintForDefault(Model{Name: "Reverse sorter", Type: ChallengeTypePlayerCreated})
+	assert.NotNil(t, err)
+}
+
+func TestSeededHints(t *testing.T) {
+	newService := func(seed int64) IService {
+		service, err := NewServiceWithModules(NewRepository(), NewDefaultRegistry(), PackWarmup, gofakeit.New(seed))
+		assert.Nil(t, err)
+		assert.Nil(t, service.AddDefaultModules())
+		return service
+	}
+	generate := func(service IService) [][]interface{} {
+		hints := [][]interface{}{}
+		for i := 0; i < 3; i++ {
+			for _, c := range service.GetChallenges() {
+				generated, err := service.GenerateHintForDefault(c)
+				assert.Nil(t, err)
+				hints = append(hints, generated)
+			}
+		}
+		return hints
+	}
+	// A fixed seed reproduces the hints of a game exactly
+	first := generate(newService(42))
+	assert.Equal(t, first, generate(newService(42)))
+	assert.NotEqual(t, first, generate(newService(43)))
+
+	service := newService(7)
+	var target Model
+	for _, c := range service.GetChallenges() {
+		if c.Name == "Binary conversion" {
+			target = c
+		}
+	}
+	binary, err := service.GenerateHintForDefault(target)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{113113}, binary)
+}
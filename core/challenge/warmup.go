@@ -0,0 +1,617 @@
+package challenge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// Warm-up modules of the built in registry
+
+// Shifts the letters of a text in the alphabet
+type caesarShift struct{}
+
+// Interface check
+var _ IModule = (*caesarShift)(nil)
+
+func (caesarShift) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "Caesar shift",
+		Description: "The first hint is a lowercase text, the second one is a number between 1 and 25. Shift every letter of the text forward in the alphabet by the number (z wraps around to a), keep every other character as it is and send the result as the first solution",
+		Example: Example{
+			Hints:     []interface{}{"hello, world", 3},
+			Solutions: []interface{}{"khoor, zruog"},
+		},
+	}
+}
+
+func (caesarShift) GenerateHints(random *gofakeit.Faker) []interface{} {
+	return []interface{}{strings.ToLower(random.Sentence(random.Number(3, 6))), random.Number(1, 25)}
+}
+
+func (caesarShift) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	text, err := getString(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	shift, err := getInteger(hints, 1, "Hints")
+	if err != nil {
+		return false, err
+	}
+	solution, err := getString(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	shifted := strings.Map(func(r rune) rune {
+		if r < 'a' || r > 'z' {
+			return r
+		}
+		return 'a' + (r-'a'+rune(shift))%26
+	}, text)
+	return shifted == solution, nil
+}
+
+// Plays FizzBuzz on a range of numbers
+type fizzBuzzRange struct{}
+
+// Interface check
+var _ IModule = (*fizzBuzzRange)(nil)
+
+func (fizzBuzzRange) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "FizzBuzz range",
+		Description: "The hints are the first and the last number of a range. Send a solution for every number of the range (both included) in order: \"FizzBuzz\" if the number is divisible by 15, \"Fizz\" if it is divisible by 3, \"Buzz\" if it is divisible by 5, otherwise the number itself as a string",
+		Example: Example{
+			Hints:     []interface{}{9, 15},
+			Solutions: []interface{}{"Fizz", "Buzz", "11", "Fizz", "13", "14", "FizzBuzz"},
+		},
+	}
+}
+
+func (fizzBuzzRange) GenerateHints(random *gofakeit.Faker) []interface{} {
+	from := random.Number(1, 100)
+	return []interface{}{from, from + random.Number(5, 20)}
+}
+
+func (fizzBuzzRange) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	from, err := getInteger(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	to, err := getInteger(hints, 1, "Hints")
+	if err != nil {
+		return false, err
+	}
+	expected := []interface{}{}
+	for i := from; i <= to; i++ {
+		switch {
+		case i%15 == 0:
+			expected = append(expected, "FizzBuzz")
+		case i%3 == 0:
+			expected = append(expected, "Fizz")
+		case i%5 == 0:
+			expected = append(expected, "Buzz")
+		default:
+			expected = append(expected, strconv.Itoa(i))
+		}
+	}
+	return isSameJSON(expected, solutions), nil
+}
+
+// Sums a field of JSON encoded objects
+type jsonFieldSum struct{}
+
+// Interface check
+var _ IModule = (*jsonFieldSum)(nil)
+
+func (jsonFieldSum) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyMedium,
+		Name:        "JSON field sum",
+		Description: "The first hint is a JSON encoded array of objects, the second one is the name of a field. Sum the values of the field in every object (objects without the field count as 0) and send the sum as the first solution",
+		Example: Example{
+			Hints:     []interface{}{`[{"price":3,"qty":1},{"price":4},{"qty":2}]`, "price"},
+			Solutions: []interface{}{7},
+		},
+	}
+}
+
+func (jsonFieldSum) GenerateHints(random *gofakeit.Faker) []interface{} {
+	fields := []string{"price", "qty", "weight"}
+	objects := []map[string]int{}
+	for i := random.Number(3, 8); i > 0; i-- {
+		object := map[string]int{}
+		for _, field := range fields {
+			if random.Bool() {
+				object[field] = random.Number(1, 100)
+			}
+		}
+		objects = append(objects, object)
+	}
+	encoded, _ := json.Marshal(objects)
+	return []interface{}{string(encoded), fields[random.Number(0, len(fields)-1)]}
+}
+
+func (jsonFieldSum) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	encoded, err := getString(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	field, err := getString(hints, 1, "Hints")
+	if err != nil {
+		return false, err
+	}
+	solution, err := getNumber(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	objects := []map[string]interface{}{}
+	if err = json.Unmarshal([]byte(encoded), &objects); err != nil {
+		return false, err
+	}
+	sum := float64(0)
+	for _, object := range objects {
+		if value, ok := object[field].(float64); ok {
+			sum += value
+		}
+	}
+	return sum == solution, nil
+}
+
+// Encodes or decodes base64 texts
+type base64RoundTrip struct{}
+
+// Interface check
+var _ IModule = (*base64RoundTrip)(nil)
+
+func (base64RoundTrip) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "Base64 round-trip",
+		Description: "The first hint is either \"encode\" or \"decode\", the second one is a text. Encode the text to standard base64 or decode it from standard base64 and send the result as the first solution",
+		Example: Example{
+			Hints:     []interface{}{"encode", "centurion"},
+			Solutions: []interface{}{"Y2VudHVyaW9u"},
+		},
+	}
+}
+
+func (base64RoundTrip) GenerateHints(random *gofakeit.Faker) []interface{} {
+	text := fmt.Sprintf("%s %s", random.Word(), random.HipsterWord())
+	if random.Bool() {
+		return []interface{}{"decode", base64.StdEncoding.EncodeToString([]byte(text))}
+	}
+	return []interface{}{"encode", text}
+}
+
+func (base64RoundTrip) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	operation, err := getString(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	text, err := getString(hints, 1, "Hints")
+	if err != nil {
+		return false, err
+	}
+	solution, err := getString(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	switch operation {
+	case "encode":
+		return base64.StdEncoding.EncodeToString([]byte(text)) == solution, nil
+	case "decode":
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return false, err
+		}
+		return string(decoded) == solution, nil
+	default:
+		return false, fmt.Errorf("%s is not a known operation", operation)
+	}
+}
+
+// Transposes a matrix
+type matrixTranspose struct{}
+
+// Interface check
+var _ IModule = (*matrixTranspose)(nil)
+
+func (matrixTranspose) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyMedium,
+		Name:        "Matrix transpose",
+		Description: "The first hint is a matrix as an array of rows. Send its transpose (the rows become the columns) as the first solution in the same format",
+		Example: Example{
+			Hints:     []interface{}{[][]int{{1, 2, 3}, {4, 5, 6}}},
+			Solutions: []interface{}{[][]int{{1, 4}, {2, 5}, {3, 6}}},
+		},
+	}
+}
+
+func (matrixTranspose) GenerateHints(random *gofakeit.Faker) []interface{} {
+	matrix := make([][]int, random.Number(2, 4))
+	columns := random.Number(2, 4)
+	for i := range matrix {
+		matrix[i] = make([]int, columns)
+		for j := range matrix[i] {
+			matrix[i][j] = random.Number(0, 9)
+		}
+	}
+	return []interface{}{matrix}
+}
+
+func (matrixTranspose) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	matrix, err := getArray(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	if _, err = getArray(solutions, 0, "Solutions"); err != nil {
+		return false, err
+	}
+	transposed := [][]interface{}{}
+	for _, row := range matrix {
+		values, ok := row.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("Rows of the matrix have to be arrays")
+		}
+		for j, value := range values {
+			if j == len(transposed) {
+				transposed = append(transposed, []interface{}{})
+			}
+			transposed[j] = append(transposed[j], value)
+		}
+	}
+	return isSameJSON(transposed, solutions[0]), nil
+}
+
+// Factorises a number into primes
+type primeFactorisation struct{}
+
+// Interface check
+var _ IModule = (*primeFactorisation)(nil)
+
+func (primeFactorisation) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyMedium,
+		Name:        "Prime factorisation",
+		Description: "The first hint is a number greater than 1. Send its prime factors in ascending order as the solutions, a factor is repeated as many times as it divides the number",
+		Example: Example{
+			Hints:     []interface{}{60},
+			Solutions: []interface{}{2, 2, 3, 5},
+		},
+	}
+}
+
+func (primeFactorisation) GenerateHints(random *gofakeit.Faker) []interface{} {
+	return []interface{}{random.Number(2, 100000)}
+}
+
+func (primeFactorisation) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	n, err := getInteger(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	if n < 2 {
+		return false, fmt.Errorf("%d cannot be factorised", n)
+	}
+	factors := []int{}
+	for factor := 2; factor*factor <= n; factor++ {
+		for n%factor == 0 {
+			factors = append(factors, factor)
+			n /= factor
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return isSameJSON(factors, solutions), nil
+}
+
+// Groups anagrams together
+type anagramGrouping struct{}
+
+// Interface check
+var _ IModule = (*anagramGrouping)(nil)
+
+func (anagramGrouping) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyHard,
+		Name:        "Anagram grouping",
+		Description: "The hints are words. Group the words which are anagrams of each other and send every group as an array of words in the solutions. Neither the order of the groups nor the order of the words in a group matters",
+		Example: Example{
+			Hints:     []interface{}{"listen", "google", "silent", "enlist", "banana"},
+			Solutions: []interface{}{[]string{"listen", "silent", "enlist"}, []string{"google"}, []string{"banana"}},
+		},
+	}
+}
+
+func (anagramGrouping) GenerateHints(random *gofakeit.Faker) []interface{} {
+	hints := []interface{}{}
+	for i := random.Number(3, 4); i > 0; i-- {
+		letters := strings.Split(strings.ToLower(random.Word()), "")
+		for j := random.Number(1, 3); j > 0; j-- {
+			random.ShuffleStrings(letters)
+			hints = append(hints, strings.Join(letters, ""))
+		}
+	}
+	random.ShuffleAnySlice(hints)
+	return hints
+}
+
+func (anagramGrouping) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	groups := map[string][]string{}
+	for i := range hints {
+		word, err := getString(hints, i, "Hints")
+		if err != nil {
+			return false, err
+		}
+		groups[sortLetters(word)] = append(groups[sortLetters(word)], word)
+	}
+	expected := [][]string{}
+	for _, group := range groups {
+		expected = append(expected, group)
+	}
+	received := [][]string{}
+	for i := range solutions {
+		words, err := getArray(solutions, i, "Solutions")
+		if err != nil {
+			return false, err
+		}
+		group := []string{}
+		for j := range words {
+			word, err := getString(words, j, "Groups")
+			if err != nil {
+				return false, err
+			}
+			group = append(group, word)
+		}
+		received = append(received, group)
+	}
+	return isSameJSON(sortGroups(expected), sortGroups(received)), nil
+}
+
+// Returns the letters of a word in alphabetical order
+func sortLetters(word string) string {
+	letters := strings.Split(word, "")
+	sort.Strings(letters)
+	return strings.Join(letters, "")
+}
+
+// Sorts the words in every group and the groups by their words
+func sortGroups(groups [][]string) [][]string {
+	for _, group := range groups {
+		sort.Strings(group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.Join(groups[i], ",") < strings.Join(groups[j], ",")
+	})
+	return groups
+}
+
+// Counts the vowels of a text
+type vowelCounter struct{}
+
+// Interface check
+var _ IModule = (*vowelCounter)(nil)
+
+func (vowelCounter) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "Vowel counter",
+		Description: "The first hint is a text. Count the vowels (a, e, i, o and u, case insensitive) in it and send the count as the first solution",
+		Example: Example{
+			Hints:     []interface{}{"Centurion"},
+			Solutions: []interface{}{4},
+		},
+	}
+}
+
+func (vowelCounter) GenerateHints(random *gofakeit.Faker) []interface{} {
+	return []interface{}{random.Sentence(random.Number(3, 8))}
+}
+
+func (vowelCounter) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	text, err := getString(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	solution, err := getNumber(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	count := 0
+	for _, r := range strings.ToLower(text) {
+		if strings.ContainsRune("aeiou", r) {
+			count++
+		}
+	}
+	return float64(count) == solution, nil
+}
+
+// Tells which words are palindromes
+type palindromeCheck struct{}
+
+// Interface check
+var _ IModule = (*palindromeCheck)(nil)
+
+func (palindromeCheck) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "Palindrome check",
+		Description: "The hints are words. Send a solution for every word in the same order: true if the word reads the same backwards (case insensitive), false otherwise",
+		Example: Example{
+			Hints:     []interface{}{"Level", "centurion", "noon"},
+			Solutions: []interface{}{true, false, true},
+		},
+	}
+}
+
+func (palindromeCheck) GenerateHints(random *gofakeit.Faker) []interface{} {
+	hints := []interface{}{}
+	for i := random.Number(4, 7); i > 0; i-- {
+		word := random.Word()
+		if random.Bool() {
+			word += reverse(word[:len(word)-random.Number(0, 1)])
+		}
+		hints = append(hints, word)
+	}
+	return hints
+}
+
+func (palindromeCheck) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	expected := []bool{}
+	for i := range hints {
+		word, err := getString(hints, i, "Hints")
+		if err != nil {
+			return false, err
+		}
+		word = strings.ToLower(word)
+		expected = append(expected, word == reverse(word))
+	}
+	return isSameJSON(expected, solutions), nil
+}
+
+// Returns a given text backwards
+func reverse(text string) string {
+	runes := []rune(text)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// Writes numbers in roman numerals
+type romanNumerals struct{}
+
+// Interface check
+var _ IModule = (*romanNumerals)(nil)
+
+func (romanNumerals) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyMedium,
+		Name:        "Roman numerals",
+		Description: "The first hint is a number between 1 and 3999. Send it written in roman numerals (e.g. 1994 is MCMXCIV) as the first solution",
+		Example: Example{
+			Hints:     []interface{}{2024},
+			Solutions: []interface{}{"MMXXIV"},
+		},
+	}
+}
+
+func (romanNumerals) GenerateHints(random *gofakeit.Faker) []interface{} {
+	return []interface{}{random.Number(1, 3999)}
+}
+
+func (romanNumerals) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	n, err := getInteger(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	solution, err := getString(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var numeral strings.Builder
+	for i, value := range values {
+		for n >= value {
+			numeral.WriteString(symbols[i])
+			n -= value
+		}
+	}
+	return numeral.String() == solution, nil
+}
+
+// Compresses a text with run-length encoding
+type runLengthEncoding struct{}
+
+// Interface check
+var _ IModule = (*runLengthEncoding)(nil)
+
+func (runLengthEncoding) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyMedium,
+		Name:        "Run-length encoding",
+		Description: "The first hint is a text. Replace every run of the same character with the character followed by the length of the run (e.g. aaab becomes a3b1) and send the result as the first solution",
+		Example: Example{
+			Hints:     []interface{}{"aaabccdddd"},
+			Solutions: []interface{}{"a3b1c2d4"},
+		},
+	}
+}
+
+func (runLengthEncoding) GenerateHints(random *gofakeit.Faker) []interface{} {
+	var text strings.Builder
+	for i := random.Number(4, 8); i > 0; i-- {
+		text.WriteString(strings.Repeat(random.RandomString([]string{"a", "b", "c"}), random.Number(1, 6)))
+	}
+	return []interface{}{text.String()}
+}
+
+func (runLengthEncoding) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	text, err := getString(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	solution, err := getString(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	var encoded strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		encoded.WriteString(fmt.Sprintf("%c%d", runes[i], j-i))
+		i = j
+	}
+	return encoded.String() == solution, nil
+}
+
+// Converts numbers to binary
+type binaryConversion struct{}
+
+// Interface check
+var _ IModule = (*binaryConversion)(nil)
+
+func (binaryConversion) GetChallenge() Model {
+	return Model{
+		Difficulty:  DifficultyEasy,
+		Name:        "Binary conversion",
+		Description: "The first hint is a non-negative number. Send its binary representation without leading zeros as a string in the first solution",
+		Example: Example{
+			Hints:     []interface{}{10},
+			Solutions: []interface{}{"1010"},
+		},
+	}
+}
+
+func (binaryConversion) GenerateHints(random *gofakeit.Faker) []interface{} {
+	return []interface{}{random.Number(0, 1<<20)}
+}
+
+func (binaryConversion) Validate(hints []interface{}, solutions []interface{}) (bool, error) {
+	n, err := getInteger(hints, 0, "Hints")
+	if err != nil {
+		return false, err
+	}
+	if n < 0 {
+		return false, fmt.Errorf("%d is negative", n)
+	}
+	solution, err := getString(solutions, 0, "Solutions")
+	if err != nil {
+		return false, err
+	}
+	return strconv.FormatInt(int64(n), 2) == solution, nil
+}
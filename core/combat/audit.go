@@ -0,0 +1,19 @@
+package combat
+
+// Describes how a defender did on the determinism audits
+// NOTE: An audit fails if the defender rejects the example
+// of its own challenge or does not answer in time
+type AuditResult struct {
+	Passed int
+	Failed int
+}
+
+// Returns the number of finished audits
+func (r AuditResult) GetTotal() int {
+	return r.Passed + r.Failed
+}
+
+// Returns true if the defender contradicted its own examples at least once
+func (r AuditResult) IsFlagged() bool {
+	return r.Failed > 0
+}
@@ -2,25 +2,81 @@ package combat
 
 import "time"
 
+// Defender ID of the combats against default modules
+// which are defended by the system itself
+const SystemDefenderID = "system"
+
+// Attacker ID of the verification and audit combats
+// in which the system probes a challenge
+const SystemAttackerID = "system"
+
+// Types of the combats
+const (
+	// Attack of a player on a challenge
+	CombatTypeAttack = "attack"
+	// Probe of a new challenge with its example solution
+	// which the defender has to accept
+	CombatTypeVerification = "verification"
+	// Probe of a new challenge with a corrupted example solution
+	// which the defender has to reject
+	CombatTypeVerificationCorrupted = "verification_corrupted"
+	// Canary evaluation replaying the example of a published challenge
+	// which the defender has to accept
+	CombatTypeAudit = "audit"
+)
+
 // Describes a combat in the system
 // which can happen between attackers and defenders
 type Model struct {
 	// ID of the combat
 	ID string
+	// Type of the combat (see consts for types)
+	// NOTE: Combats stored without a type are attacks
+	Type string
 	// ID of the challenge being solved
 	ChallengeID string
+	// Version of the challenge the combat is fought on
+	// NOTE: Combats stored without a version refer to the current version
+	ChallengeVersion int
 	// ID of the attacker
 	AttackerID string
 	// ID of the defender
 	DefenderID string
 	// Current state of the combat
 	CombatState string
+	// Hints issued to the attacker by the defender
+	// NOTE: Only the hints stored here are accepted with a solution
+	Hints []interface{}
+	// True if the module of a hosted challenge serves the combat
+	// instead of the defender
+	ServedByModule bool
 	// Time of creation
 	CreatedAt time.Time
 	// Time of the last update on the model
 	LastUpdateAt time.Time
 }
 
+// Returns true if the combat is against a default module
+func (m Model) IsAgainstSystem() bool {
+	return m.DefenderID == SystemDefenderID
+}
+
+// Returns true if the combat is a verification probe of a new challenge
+func (m Model) IsVerification() bool {
+	return m.Type == CombatTypeVerification || m.Type == CombatTypeVerificationCorrupted
+}
+
+// Returns true if the combat is a determinism audit of a published challenge
+func (m Model) IsAudit() bool {
+	return m.Type == CombatTypeAudit
+}
+
+// Returns true if the combat is initiated by the system
+// to check the defender instead of an attacker
+func (m Model) IsProbe() bool {
+	return m.IsVerification() || m.IsAudit()
+}
+
 // Returns if the state is in the final stage
 // which indicates that it should be immutable
 func (m Model) IsInFinalState() bool {
@@ -0,0 +1,42 @@
+package combat
+
+// Describes which attackers solved which challenges
+// by challenge ID and attacker ID
+type CompletionMatrix map[string]map[string]bool
+
+// Marks a challenge solved by a given attacker
+func (m CompletionMatrix) add(challengeID string, attackerID string) {
+	if _, ok := m[challengeID]; !ok {
+		m[challengeID] = map[string]bool{}
+	}
+	m[challengeID][attackerID] = true
+}
+
+// Returns true if a given attacker solved a given challenge
+func (m CompletionMatrix) IsCompletedBy(challengeID string, attackerID string) bool {
+	return m[challengeID][attackerID]
+}
+
+// Returns the IDs of the attackers who solved a given challenge
+func (m CompletionMatrix) GetSolvers(challengeID string) []string {
+	solvers := []string{}
+	for attackerID := range m[challengeID] {
+		solvers = append(solvers, attackerID)
+	}
+	return solvers
+}
+
+// Returns the number of different attackers who solved a given challenge
+func (m CompletionMatrix) GetSolverCount(challengeID string) int {
+	return len(m[challengeID])
+}
+
+// Returns the number of different solvers by challenge ID
+// unsolved challenges are not included
+func (m CompletionMatrix) GetSolverCounts() map[string]int {
+	counts := map[string]int{}
+	for challengeID, attackers := range m {
+		counts[challengeID] = len(attackers)
+	}
+	return counts
+}
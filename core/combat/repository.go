@@ -6,8 +6,15 @@ import (
 	"time"
 
 	"github.com/riltech/centurion/core/logger"
+	"github.com/riltech/centurion/core/storage"
 )
 
+// Bucket of the active combats in the store
+const storeBucket = "combats"
+
+// Bucket of the finished combats in the store
+const archiveBucket = "combat_archive"
+
 // Describes a repository for the combat package
 type IRepository interface {
 	// Fetches the available combats in the system
@@ -15,9 +22,17 @@ type IRepository interface {
 	// Adds a new combat to the system
 	AddCombat(Model) error
 	// Finds a combat by ID
+	// NOTE: Archived combats are included
 	FindByID(ID string) (Model, error)
 	// Updates combat state
 	UpdateCombatState(ID string, state string) (Model, error)
+	// Updates combat state only if the combat is in a given state
+	// NOTE: The check and the update are atomic
+	CompareAndUpdateCombatState(ID string, expected string, state string) (Model, error)
+	// Moves the last update of every active combat later by a given duration
+	PostponeCombats(d time.Duration) error
+	// Stores the hints issued to the attacker in the combat
+	UpdateCombatHints(ID string, hints []interface{}) (Model, error)
 	// Returns the archive (aka finished events)
 	GetArchive() []Model
 }
@@ -27,6 +42,10 @@ type Repository struct {
 	mux     sync.RWMutex
 	combats []Model
 	archive []Model
+	// Optional persistence, nil when running in memory only
+	store         storage.IStore
+	storedCombats *storage.Collection
+	storedArchive *storage.Collection
 }
 
 // Interface check
@@ -39,23 +58,24 @@ func (r *Repository) AddCombat(combat Model) error {
 	r.mux.Lock()
 	defer r.mux.Unlock()
 	combat.CreatedAt = time.Now()
-	if r.combats == nil {
-		r.combats = []Model{combat}
-		return nil
-	}
+	combat.LastUpdateAt = combat.CreatedAt
 	for _, c := range r.combats {
 		if c.ID == combat.ID {
 			return fmt.Errorf("Cannot use the same id (expected, got) (%s, %s)", combat.ID, c.ID)
 		}
 	}
 	r.combats = append(r.combats, combat)
-	return nil
+	return r.persist(combat)
 }
 
 func (r *Repository) GetCombats() []Model {
 	defer r.mux.RUnlock()
 	r.mux.RLock()
-	return r.combats
+	// Active combats are moved around on archiving
+	// so a copy is returned to avoid races
+	combats := make([]Model, len(r.combats))
+	copy(combats, r.combats)
+	return combats
 }
 
 func (r *Repository) FindByID(ID string) (Model, error) {
@@ -69,6 +89,11 @@ func (r *Repository) FindByID(ID string) (Model, error) {
 			return c, nil
 		}
 	}
+	for _, c := range r.archive {
+		if c.ID == ID {
+			return c, nil
+		}
+	}
 	return Model{}, fmt.Errorf("%s combat not found", ID)
 }
 
@@ -79,7 +104,7 @@ func (r *Repository) archiveElement(index int) {
 	if r == nil {
 		return
 	}
-	if len(r.combats)-1 > index {
+	if index >= len(r.combats) {
 		logger.LogError(fmt.Errorf("Could not archieve %d combat because len is %d", index, len(r.combats)))
 		return
 	}
@@ -96,12 +121,63 @@ func (r *Repository) UpdateCombatState(ID string, state string) (Model, error) {
 	for i, c := range r.combats {
 		if c.ID == ID {
 			c.CombatState = state
-			r.combats[i].CombatState = state
-			r.combats[i].LastUpdateAt = time.Now()
+			c.LastUpdateAt = time.Now()
+			r.combats[i] = c
 			if IsFinalCombatState(state) {
 				r.archiveElement(i)
 			}
-			return c, nil
+			return c, r.persist(c)
+		}
+	}
+	return Model{}, fmt.Errorf("%s not found", ID)
+}
+
+func (r *Repository) CompareAndUpdateCombatState(ID string, expected string, state string) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i, c := range r.combats {
+		if c.ID == ID {
+			if c.CombatState != expected {
+				return Model{}, fmt.Errorf("%s combat is in %s state instead of %s", ID, c.CombatState, expected)
+			}
+			c.CombatState = state
+			c.LastUpdateAt = time.Now()
+			r.combats[i] = c
+			if IsFinalCombatState(state) {
+				r.archiveElement(i)
+			}
+			return c, r.persist(c)
+		}
+	}
+	return Model{}, fmt.Errorf("%s combat is already over", ID)
+}
+
+func (r *Repository) PostponeCombats(d time.Duration) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i := range r.combats {
+		r.combats[i].LastUpdateAt = r.combats[i].LastUpdateAt.Add(d)
+	}
+	return r.persist(r.combats...)
+}
+
+func (r *Repository) UpdateCombatHints(ID string, hints []interface{}) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i, c := range r.combats {
+		if c.ID == ID {
+			r.combats[i].Hints = hints
+			r.combats[i].LastUpdateAt = time.Now()
+			return r.combats[i], r.persist(r.combats[i])
 		}
 	}
 	return Model{}, fmt.Errorf("%s not found", ID)
@@ -113,7 +189,38 @@ func (r *Repository) GetArchive() []Model {
 	}
 	r.mux.RLock()
 	defer r.mux.RUnlock()
-	return r.archive
+	// The archive keeps growing while callers read it
+	// so a copy is returned to avoid races
+	archive := make([]Model, len(r.archive))
+	copy(archive, r.archive)
+	return archive
+}
+
+// Writes given combats into the store if persistence is enabled
+// Finished combats are moved into the archive bucket
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) persist(combats ...Model) error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Update(func(tx storage.ITx) error {
+		for _, c := range combats {
+			if !c.IsInFinalState() {
+				if err := r.storedCombats.Put(tx, c.ID, c); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := r.storedCombats.Delete(tx, c.ID); err != nil {
+				return err
+			}
+			if err := r.storedArchive.Put(tx, c.ID, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Constructor to create a new engine repository
@@ -122,3 +229,30 @@ func NewRepository() *Repository {
 		mux: sync.RWMutex{},
 	}
 }
+
+// Constructor to create a repository backed by a store
+func NewPersistentRepository(store storage.IStore) (*Repository, error) {
+	r := &Repository{
+		mux:           sync.RWMutex{},
+		store:         store,
+		storedCombats: storage.NewCollection(storeBucket),
+		storedArchive: storage.NewCollection(archiveBucket),
+	}
+	if err := r.storedCombats.Load(store, func(load func(interface{}) error) (string, error) {
+		var c Model
+		err := load(&c)
+		r.combats = append(r.combats, c)
+		return c.ID, err
+	}); err != nil {
+		return nil, err
+	}
+	if err := r.storedArchive.Load(store, func(load func(interface{}) error) (string, error) {
+		var c Model
+		err := load(&c)
+		r.archive = append(r.archive, c)
+		return c.ID, err
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
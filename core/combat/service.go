@@ -1,6 +1,12 @@
 package combat
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/riltech/centurion/core/logger"
+)
 
 // Describes a combat service interface
 type IService interface {
@@ -11,8 +17,18 @@ type IService interface {
 	// Updates the CombatState of a given combat
 	// Find available states in the package
 	UpdateCombatState(ID string, state string) (Model, error)
-	// Find by attacker id and challenge id
+	// Updates the CombatState of a given combat only if it is in the expected state
+	// NOTE: Only the caller succeeding with the update can score the combat
+	CompareAndUpdateCombatState(ID string, expected string, state string) (Model, error)
+	// Stores the hints issued to the attacker for a given combat
+	SetIssuedHints(ID string, hints []interface{}) (Model, error)
+	// Finds the latest active combat of an attacker on a challenge
 	FindByAttackerAndChallenge(attackerID string, challengeID string) (Model, error)
+	// Moves the active combats of an attacker on a challenge into a failed state
+	// except the ones waiting for the evaluation of a solution,
+	// used when a new attack replaces them
+	// returns the updated combats
+	SupersedeCombats(attackerID string, challengeID string) []Model
 	// Returns true of the attacker has already completed the given challenge before
 	// during the game session
 	IsAttackerCompletedBefore(attackerID string, challengeID string) bool
@@ -22,18 +38,64 @@ type IService interface {
 	// Returns the percentages of successful attack events
 	// values are between 0-100
 	GetAttackerSuccessPercent() int
-	// Returns if the solution is the 5th or 10th or 15th (etc..)
-	// NOTE: the given challengeId has to be pre-solved in combat
-	IsFifthUniqueSolution(attackerID string, challengeID string) bool
+	// Returns the number of unique challenges solved by the attacker
+	// counting the given challenge as solved
+	// NOTE: Challenges defended by the system are not counted
+	GetUniqueSolutionCount(attackerID string, challengeID string) int
 	// Calculates how many percentage of challenges the attackers were able to solve
 	GetOverallAttackerSuccessPrecent(numberOfUniqueChallenges int) int
-	// Returns the number of how many attackers completed a given challenge
-	GetNumberOfUniqueCompletionsPerChallenges() map[string]uint
+	// Returns which attackers solved which challenges
+	// based on the successful attacks in the archive
+	GetCompletionMatrix() CompletionMatrix
+	// Returns the audit results of the defenders keyed by defender ID
+	GetAuditResults() map[string]AuditResult
+	// Returns the finished attacks on a given challenge
+	// in which the defender rejected the solution
+	GetRejectedSolutions(challengeID string) []Model
+	// Moves every combat which passed its deadline into a failed state
+	// returns the updated combats
+	ReapExpiredCombats(now time.Time) []Model
+	// Moves every combat which waits for an answer from a given player
+	// into a failed state, used when the player goes offline
+	// returns the updated combats
+	FailCombatsWaitingOn(playerID string) []Model
+	// Freezes the deadlines of the combats while the game is not running
+	// NOTE: Resuming postpones the deadlines by the time they were frozen
+	SetRunning(running bool) error
+	// Starts reaping expired combats periodically in the background
+	// onReaped is called with every combat moved into a failed state
+	StartReaper(onReaped func(Model))
+	// Stops the background reaper
+	StopReaper()
+}
+
+// Describes the deadlines of the states in which
+// one side of the combat is waiting for the other
+// NOTE: A zero duration disables the deadline
+type Timeouts struct {
+	// Deadline for the defender to provide hints
+	DefenseRequested time.Duration
+	// Deadline for the attacker to provide a solution
+	AttackerChallenged time.Duration
+	// Deadline for the defender to evaluate a solution
+	SolutionEvaluationRequested time.Duration
+	// Interval of checking for expired combats
+	ReaperInterval time.Duration
 }
 
 // Service implementation
 type Service struct {
 	repository IRepository
+	timeouts   Timeouts
+	stopReaper chan uint8
+	deadlines  *deadlineFreeze
+}
+
+// Keeps track of the time since the deadlines are frozen
+type deadlineFreeze struct {
+	mux sync.Mutex
+	// Zero while the deadlines are running
+	frozenAt time.Time
 }
 
 // Interface check
@@ -55,22 +117,38 @@ func (s *Service) UpdateCombatState(ID string, state string) (Model, error) {
 	if err != nil {
 		return Model{}, err
 	}
-	found := false
+	if !isValidCombatState(state) {
+		return Model{}, fmt.Errorf("%s is not a valid state", state)
+	}
+	return s.repository.UpdateCombatState(m.ID, state)
+}
+
+// Returns true if a given state is a known combat state
+func isValidCombatState(state string) bool {
 	for _, validState := range CombatStateCollection {
 		if state == validState {
-			found = true
-			break
+			return true
 		}
 	}
-	if !found {
+	return false
+}
+
+func (s *Service) CompareAndUpdateCombatState(ID string, expected string, state string) (Model, error) {
+	if !isValidCombatState(state) {
 		return Model{}, fmt.Errorf("%s is not a valid state", state)
 	}
-	return s.repository.UpdateCombatState(m.ID, state)
+	return s.repository.CompareAndUpdateCombatState(ID, expected, state)
+}
+
+func (s Service) SetIssuedHints(ID string, hints []interface{}) (Model, error) {
+	return s.repository.UpdateCombatHints(ID, hints)
 }
 
 func (s Service) FindByAttackerAndChallenge(attackerID string, challengeID string) (Model, error) {
 	combats := s.repository.GetCombats()
-	for _, c := range combats {
+	// Combats are stored in the order of creation
+	for i := len(combats) - 1; i >= 0; i-- {
+		c := combats[i]
 		if c.AttackerID != attackerID {
 			continue
 		}
@@ -82,8 +160,29 @@ func (s Service) FindByAttackerAndChallenge(attackerID string, challengeID strin
 	return Model{}, fmt.Errorf("Not found")
 }
 
+func (s *Service) SupersedeCombats(attackerID string, challengeID string) []Model {
+	superseded := []Model{}
+	for _, c := range s.repository.GetCombats() {
+		if c.AttackerID != attackerID || c.ChallengeID != challengeID {
+			continue
+		}
+		// The solution was already sent, it is evaluated as usual
+		if c.CombatState == CombatStateSolutionEvaluationRequested {
+			continue
+		}
+		updated, err := s.repository.CompareAndUpdateCombatState(c.ID, c.CombatState, CombatStateAttackFailed)
+		if err != nil {
+			// The combat was most likely moved on in the meantime
+			logger.LogError(err)
+			continue
+		}
+		superseded = append(superseded, updated)
+	}
+	return superseded
+}
+
 func (s Service) IsAttackerCompletedBefore(attackerID, challengeID string) bool {
-	finishedCombats := s.repository.GetArchive()
+	finishedCombats := s.getAttackArchive()
 	for _, c := range finishedCombats {
 		if c.AttackerID == attackerID && c.ChallengeID == challengeID && c.CombatState == CombatStateAttackSucceeded {
 			return true
@@ -94,7 +193,7 @@ func (s Service) IsAttackerCompletedBefore(attackerID, challengeID string) bool
 
 func (s Service) GetDefenseFailPercent() int {
 	failedEvents := []Model{}
-	archive := s.repository.GetArchive()
+	archive := s.getAttackArchive()
 	for _, c := range archive {
 		if c.CombatState == CombatStateDefenseFailed {
 			failedEvents = append(failedEvents, c)
@@ -109,7 +208,7 @@ func (s Service) GetDefenseFailPercent() int {
 func (s Service) GetAttackerSuccessPercent() int {
 	successEvents := []Model{}
 	validEventsOverall := []Model{}
-	archive := s.repository.GetArchive()
+	archive := s.getAttackArchive()
 	for _, c := range archive {
 		if c.CombatState == CombatStateAttackSucceeded {
 			successEvents = append(successEvents, c)
@@ -131,27 +230,19 @@ func (s Service) GetAttackerSuccessPercent() int {
 	return int(float32(len(successEvents)) / float32(len(validEventsOverall)) * 100)
 }
 
-func (s Service) IsFifthUniqueSolution(attackerID string, challengeID string) bool {
-	archive := s.repository.GetArchive()
-	uniqueCompleted := map[string]int{}
-	for _, item := range archive {
-		if item.AttackerID == attackerID {
-			uniqueCompleted[item.ChallengeID] = 1
+func (s Service) GetUniqueSolutionCount(attackerID string, challengeID string) int {
+	uniqueCompleted := map[string]bool{challengeID: true}
+	for _, item := range s.getAttackArchive() {
+		if item.AttackerID == attackerID && item.CombatState == CombatStateAttackSucceeded && !item.IsAgainstSystem() {
+			uniqueCompleted[item.ChallengeID] = true
 		}
 	}
-	toStartWith := 0
-	if _, ok := uniqueCompleted[challengeID]; !ok {
-		toStartWith++
-	}
-	for range uniqueCompleted {
-		toStartWith++
-	}
-	return toStartWith%5 == 0
+	return len(uniqueCompleted)
 }
 
 func (s Service) GetOverallAttackerSuccessPrecent(numberOfUniqueChallenges int) int {
 	uniques := map[string]uint8{}
-	for _, a := range s.repository.GetArchive() {
+	for _, a := range s.getAttackArchive() {
 		if a.CombatState == CombatStateAttackSucceeded {
 			uniques[a.ChallengeID] = 1
 		}
@@ -162,21 +253,178 @@ func (s Service) GetOverallAttackerSuccessPrecent(numberOfUniqueChallenges int)
 	return int((float32(len(uniques)) / float32(numberOfUniqueChallenges)) * 100)
 }
 
-func (s Service) GetNumberOfUniqueCompletionsPerChallenges() map[string]uint {
-	challengeCompletion := map[string]uint{}
-	archive := s.repository.GetArchive()
-	for _, a := range archive {
-		if a.CombatState != CombatStateAttackSucceeded {
+func (s Service) GetCompletionMatrix() CompletionMatrix {
+	matrix := CompletionMatrix{}
+	for _, c := range s.getAttackArchive() {
+		if c.CombatState == CombatStateAttackSucceeded {
+			matrix.add(c.ChallengeID, c.AttackerID)
+		}
+	}
+	return matrix
+}
+
+func (s Service) GetAuditResults() map[string]AuditResult {
+	results := map[string]AuditResult{}
+	for _, c := range s.repository.GetArchive() {
+		if !c.IsAudit() {
 			continue
 		}
-		if _, ok := challengeCompletion[a.ID]; !ok {
-			challengeCompletion[a.ID] = 0
+		result := results[c.DefenderID]
+		if c.CombatState == CombatStateDefenseSucceeded {
+			result.Passed++
+		} else {
+			result.Failed++
+		}
+		results[c.DefenderID] = result
+	}
+	return results
+}
+
+func (s Service) GetRejectedSolutions(challengeID string) []Model {
+	rejected := []Model{}
+	for _, c := range s.getAttackArchive() {
+		if c.ChallengeID == challengeID && c.CombatState == CombatStateDefenseSucceeded {
+			rejected = append(rejected, c)
 		}
-		challengeCompletion[a.ID] = challengeCompletion[a.ID] + 1
 	}
-	return challengeCompletion
+	return rejected
 }
 
-func NewService(repository IRepository) IService {
-	return &Service{repository}
+// Returns the finished combats without the probes of the system
+// NOTE: Verification and audit probes do not count in any statistics
+func (s Service) getAttackArchive() []Model {
+	attacks := []Model{}
+	for _, c := range s.repository.GetArchive() {
+		if !c.IsProbe() {
+			attacks = append(attacks, c)
+		}
+	}
+	return attacks
+}
+
+// Returns the state an expired combat has to be moved into
+// based on which side of the combat stalled
+func (s Service) getExpiredState(m Model, now time.Time) (string, bool) {
+	var deadline time.Duration
+	var state string
+	switch m.CombatState {
+	case CombatStateDefenseRequested:
+		deadline, state = s.timeouts.DefenseRequested, CombatStateDefenseFailed
+	case CombatStateSolutionEvaluationRequested:
+		deadline, state = s.timeouts.SolutionEvaluationRequested, CombatStateDefenseFailed
+	case CombatStateAttackerChallenged:
+		deadline, state = s.timeouts.AttackerChallenged, CombatStateAttackFailed
+	default:
+		return "", false
+	}
+	if deadline <= 0 {
+		return "", false
+	}
+	lastUpdate := m.LastUpdateAt
+	if lastUpdate.IsZero() {
+		lastUpdate = m.CreatedAt
+	}
+	return state, now.Sub(lastUpdate) > deadline
+}
+
+func (s *Service) ReapExpiredCombats(now time.Time) []Model {
+	reaped := []Model{}
+	// NOTE: The lock is held so the deadlines cannot be postponed meanwhile
+	s.deadlines.mux.Lock()
+	defer s.deadlines.mux.Unlock()
+	if !s.deadlines.frozenAt.IsZero() {
+		return reaped
+	}
+	for _, c := range s.repository.GetCombats() {
+		state, expired := s.getExpiredState(c, now)
+		if !expired {
+			continue
+		}
+		updated, err := s.repository.CompareAndUpdateCombatState(c.ID, c.CombatState, state)
+		if err != nil {
+			// The combat was most likely moved on in the meantime
+			logger.LogError(err)
+			continue
+		}
+		reaped = append(reaped, updated)
+	}
+	return reaped
+}
+
+func (s *Service) FailCombatsWaitingOn(playerID string) []Model {
+	failed := []Model{}
+	for _, c := range s.repository.GetCombats() {
+		var state string
+		switch {
+		case c.DefenderID == playerID &&
+			(c.CombatState == CombatStateDefenseRequested || c.CombatState == CombatStateSolutionEvaluationRequested):
+			state = CombatStateDefenseFailed
+		case c.AttackerID == playerID && c.CombatState == CombatStateAttackerChallenged:
+			state = CombatStateAttackFailed
+		default:
+			continue
+		}
+		updated, err := s.repository.CompareAndUpdateCombatState(c.ID, c.CombatState, state)
+		if err != nil {
+			// The combat was most likely moved on in the meantime
+			logger.LogError(err)
+			continue
+		}
+		failed = append(failed, updated)
+	}
+	return failed
+}
+
+func (s *Service) SetRunning(running bool) error {
+	s.deadlines.mux.Lock()
+	defer s.deadlines.mux.Unlock()
+	if !running {
+		if s.deadlines.frozenAt.IsZero() {
+			s.deadlines.frozenAt = time.Now()
+		}
+		return nil
+	}
+	if s.deadlines.frozenAt.IsZero() {
+		return nil
+	}
+	frozen := time.Since(s.deadlines.frozenAt)
+	s.deadlines.frozenAt = time.Time{}
+	return s.repository.PostponeCombats(frozen)
+}
+
+func (s *Service) StartReaper(onReaped func(Model)) {
+	if s.timeouts.ReaperInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.timeouts.ReaperInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopReaper:
+				return
+			case now := <-ticker.C:
+				for _, c := range s.ReapExpiredCombats(now) {
+					onReaped(c)
+				}
+			}
+		}
+	}()
+}
+
+func (s *Service) StopReaper() {
+	select {
+	case s.stopReaper <- 1:
+	default:
+	}
+}
+
+// Constructor for the combat service
+func NewService(repository IRepository, timeouts Timeouts) IService {
+	return &Service{
+		repository: repository,
+		timeouts:   timeouts,
+		stopReaper: make(chan uint8, 1),
+		deadlines:  &deadlineFreeze{},
+	}
 }
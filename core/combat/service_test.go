@@ -0,0 +1,187 @@
+package combat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReapExpiredCombats(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{
+		DefenseRequested:            10 * time.Second,
+		AttackerChallenged:          time.Minute,
+		SolutionEvaluationRequested: 0,
+	})
+	for _, c := range []Model{
+		{ID: "defense", CombatState: CombatStateDefenseRequested},
+		{ID: "attack", CombatState: CombatStateAttackerChallenged},
+		{ID: "evaluation", CombatState: CombatStateSolutionEvaluationRequested},
+		{ID: "initiated", CombatState: CombatStateAttackInitiated},
+	} {
+		assert.Nil(t, repo.AddCombat(c))
+	}
+	assert.Empty(t, service.ReapExpiredCombats(time.Now()))
+
+	reaped := service.ReapExpiredCombats(time.Now().Add(30 * time.Second))
+	assert.Len(t, reaped, 1)
+	assert.Equal(t, "defense", reaped[0].ID)
+	assert.Equal(t, CombatStateDefenseFailed, reaped[0].CombatState)
+
+	reaped = service.ReapExpiredCombats(time.Now().Add(2 * time.Minute))
+	assert.Len(t, reaped, 1)
+	assert.Equal(t, "attack", reaped[0].ID)
+	assert.Equal(t, CombatStateAttackFailed, reaped[0].CombatState)
+
+	// Disabled deadlines and transient states are never reaped
+	assert.Empty(t, service.ReapExpiredCombats(time.Now().Add(time.Hour)))
+	assert.Len(t, repo.GetCombats(), 2)
+	assert.Len(t, repo.GetArchive(), 2)
+}
+
+func TestFailCombatsWaitingOn(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{})
+	for _, c := range []Model{
+		{ID: "defense", DefenderID: "def", AttackerID: "att", CombatState: CombatStateDefenseRequested},
+		{ID: "evaluation", DefenderID: "def", AttackerID: "att", CombatState: CombatStateSolutionEvaluationRequested},
+		{ID: "attack", DefenderID: "def", AttackerID: "att", CombatState: CombatStateAttackerChallenged},
+		{ID: "other", DefenderID: "other", AttackerID: "att", CombatState: CombatStateDefenseRequested},
+	} {
+		assert.Nil(t, repo.AddCombat(c))
+	}
+	failed := service.FailCombatsWaitingOn("def")
+	assert.Len(t, failed, 2)
+	for _, c := range failed {
+		assert.Equal(t, CombatStateDefenseFailed, c.CombatState)
+	}
+
+	failed = service.FailCombatsWaitingOn("att")
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "attack", failed[0].ID)
+	assert.Equal(t, CombatStateAttackFailed, failed[0].CombatState)
+	assert.Len(t, repo.GetCombats(), 1)
+}
+
+func TestSupersedeCombats(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{})
+	for _, c := range []Model{
+		{ID: "defense", AttackerID: "att", ChallengeID: "a", CombatState: CombatStateDefenseRequested},
+		{ID: "evaluation", AttackerID: "att", ChallengeID: "a", CombatState: CombatStateSolutionEvaluationRequested},
+		{ID: "attack", AttackerID: "att", ChallengeID: "a", CombatState: CombatStateAttackerChallenged},
+		{ID: "other", AttackerID: "att", ChallengeID: "b", CombatState: CombatStateAttackerChallenged},
+	} {
+		assert.Nil(t, repo.AddCombat(c))
+	}
+	// The latest combat is found
+	latest, err := service.FindByAttackerAndChallenge("att", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "attack", latest.ID)
+
+	superseded := service.SupersedeCombats("att", "a")
+	assert.Len(t, superseded, 2)
+	for _, c := range superseded {
+		assert.Equal(t, CombatStateAttackFailed, c.CombatState)
+	}
+	// Solutions under evaluation are not superseded
+	latest, err = service.FindByAttackerAndChallenge("att", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "evaluation", latest.ID)
+	assert.Len(t, repo.GetCombats(), 2)
+}
+
+func TestGetCompletionMatrix(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{})
+	for _, c := range []Model{
+		{ID: "1", ChallengeID: "a", AttackerID: "x", CombatState: CombatStateAttackSucceeded},
+		{ID: "2", ChallengeID: "a", AttackerID: "x", CombatState: CombatStateAttackSucceeded},
+		{ID: "3", ChallengeID: "a", AttackerID: "y", CombatState: CombatStateAttackSucceeded},
+		{ID: "4", ChallengeID: "b", AttackerID: "x", CombatState: CombatStateAttackSucceeded},
+		{ID: "5", ChallengeID: "c", AttackerID: "x", CombatState: CombatStateDefenseSucceeded},
+		{ID: "6", ChallengeID: "d", AttackerID: "y", DefenderID: SystemDefenderID, CombatState: CombatStateAttackSucceeded},
+	} {
+		assert.Nil(t, repo.AddCombat(c))
+		_, err := repo.UpdateCombatState(c.ID, c.CombatState)
+		assert.Nil(t, err)
+	}
+	matrix := service.GetCompletionMatrix()
+	assert.Equal(t, map[string]int{"a": 2, "b": 1, "d": 1}, matrix.GetSolverCounts())
+	assert.True(t, matrix.IsCompletedBy("a", "y"))
+	assert.False(t, matrix.IsCompletedBy("b", "y"))
+	assert.False(t, matrix.IsCompletedBy("c", "x"))
+	assert.ElementsMatch(t, []string{"x", "y"}, matrix.GetSolvers("a"))
+	assert.Equal(t, 0, matrix.GetSolverCount("c"))
+
+	// Challenges defended by the system are not unique solutions
+	assert.Equal(t, 2, service.GetUniqueSolutionCount("x", "a"))
+	assert.Equal(t, 3, service.GetUniqueSolutionCount("x", "c"))
+	assert.Equal(t, 2, service.GetUniqueSolutionCount("y", "e"))
+}
+
+func TestAudits(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{})
+	for _, c := range []Model{
+		{ID: "1", Type: CombatTypeAudit, ChallengeID: "a", DefenderID: "def", AttackerID: SystemAttackerID, CombatState: CombatStateDefenseSucceeded},
+		{ID: "2", Type: CombatTypeAudit, ChallengeID: "a", DefenderID: "def", AttackerID: SystemAttackerID, CombatState: CombatStateDefenseFailed},
+		{ID: "3", Type: CombatTypeAudit, ChallengeID: "b", DefenderID: "other", AttackerID: SystemAttackerID, CombatState: CombatStateDefenseSucceeded},
+		{ID: "4", Type: CombatTypeAttack, ChallengeID: "a", DefenderID: "def", AttackerID: "x", CombatState: CombatStateDefenseSucceeded},
+		{ID: "5", Type: CombatTypeAttack, ChallengeID: "a", DefenderID: "def", AttackerID: "y", CombatState: CombatStateAttackSucceeded},
+		{ID: "6", ChallengeID: "b", DefenderID: "other", AttackerID: "x", CombatState: CombatStateDefenseSucceeded},
+	} {
+		assert.Nil(t, repo.AddCombat(c))
+		_, err := repo.UpdateCombatState(c.ID, c.CombatState)
+		assert.Nil(t, err)
+	}
+	results := service.GetAuditResults()
+	assert.Equal(t, AuditResult{Passed: 1, Failed: 1}, results["def"])
+	assert.True(t, results["def"].IsFlagged())
+	assert.Equal(t, 2, results["def"].GetTotal())
+	assert.False(t, results["other"].IsFlagged())
+
+	rejected := service.GetRejectedSolutions("a")
+	assert.Len(t, rejected, 1)
+	assert.Equal(t, "4", rejected[0].ID)
+
+	// Audits do not count in the statistics
+	assert.Equal(t, 0, service.GetDefenseFailPercent())
+	assert.Equal(t, 33, service.GetAttackerSuccessPercent())
+}
+
+func TestCompareAndUpdateCombatState(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{})
+	assert.Nil(t, repo.AddCombat(Model{ID: "evaluation", CombatState: CombatStateSolutionEvaluationRequested}))
+
+	_, err := service.CompareAndUpdateCombatState("evaluation", CombatStateAttackerChallenged, CombatStateAttackSucceeded)
+	assert.NotNil(t, err)
+
+	updated, err := service.CompareAndUpdateCombatState("evaluation", CombatStateSolutionEvaluationRequested, CombatStateAttackSucceeded)
+	assert.Nil(t, err)
+	assert.Equal(t, CombatStateAttackSucceeded, updated.CombatState)
+
+	// A finished combat cannot be finished again (e.g. by the reaper)
+	_, err = service.CompareAndUpdateCombatState("evaluation", CombatStateSolutionEvaluationRequested, CombatStateDefenseFailed)
+	assert.NotNil(t, err)
+	assert.Len(t, repo.GetArchive(), 1)
+	assert.Equal(t, CombatStateAttackSucceeded, repo.GetArchive()[0].CombatState)
+}
+
+func TestFrozenDeadlines(t *testing.T) {
+	repo := NewRepository()
+	service := NewService(repo, Timeouts{AttackerChallenged: time.Minute})
+	assert.Nil(t, repo.AddCombat(Model{ID: "attack", CombatState: CombatStateAttackerChallenged}))
+
+	// Nothing expires while the game is not running
+	assert.Nil(t, service.SetRunning(false))
+	assert.Empty(t, service.ReapExpiredCombats(time.Now().Add(2*time.Minute)))
+
+	// The deadlines are postponed by the time they were frozen
+	service.(*Service).deadlines.frozenAt = time.Now().Add(-90 * time.Second)
+	assert.Nil(t, service.SetRunning(true))
+	assert.Empty(t, service.ReapExpiredCombats(time.Now().Add(2*time.Minute)))
+	assert.Len(t, service.ReapExpiredCombats(time.Now().Add(3*time.Minute)), 1)
+}
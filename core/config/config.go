@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
@@ -10,8 +11,68 @@ import (
 type Specification struct {
 	// Describes if the example clients are enabled
 	ExampleEnabled bool `envconfig:"example_enabled"`
+	// Describes if the game starts running right away
+	// instead of waiting in the lobby for the facilitator
+	AutoStart bool `envconfig:"auto_start" default:"true"`
+	// Length of the game, the game finishes automatically
+	// when the time is up. Zero means unlimited
+	GameDuration time.Duration `envconfig:"game_duration"`
+	// Remaining times when players are notified about the end of the game
+	CountdownMarks []time.Duration `envconfig:"countdown_marks" default:"10m,1m"`
+	// Token of the facilitator for the admin API
+	// NOTE: The admin API is disabled if it is empty
+	AdminToken string `envconfig:"admin_token"`
+	// Secret used for signing player tokens
+	// NOTE: A random secret is used (and persisted) if it is empty
+	TokenSecret string `envconfig:"token_secret"`
+	// Describes what happens when a player joins while having an active session
+	// Either "reject" (the new session is refused) or "replace" (the old session is closed)
+	SessionPolicy string `envconfig:"session_policy" default:"replace"`
+	// Interval of sending ping frames to the players, zero disables the heartbeat
+	HeartbeatInterval time.Duration `envconfig:"heartbeat_interval" default:"10s"`
+	// Time a player has to answer a ping before being considered offline
+	HeartbeatTimeout time.Duration `envconfig:"heartbeat_timeout" default:"30s"`
+	// Interval of picking defenders for a determinism audit, zero disables the audits
+	AuditInterval time.Duration `envconfig:"audit_interval" default:"1m"`
+	// Chance of a connected defender being audited in an interval (0-1)
+	AuditChance float64 `envconfig:"audit_chance" default:"0.3"`
+	// Window the attacks of an attacker are counted in for the rate limits
+	AttackWindow time.Duration `envconfig:"attack_window" default:"1m"`
+	// Attacks an attacker can launch in a window, zero disables the limit
+	AttackLimit int `envconfig:"attack_limit" default:"60"`
+	// Attacks an attacker can launch on a single challenge in a window, zero disables the limit
+	ChallengeAttackLimit int `envconfig:"challenge_attack_limit" default:"20"`
+	// Time an attacker has to wait before attacking a challenge again after
+	// a rejected solution, zero disables the cooldown
+	FailureCooldown time.Duration `envconfig:"failure_cooldown" default:"3s"`
+	// Pack of default modules installed when the game starts
+	// Either "warmup" (every built in module), "classic" (only the reverse sorter) or "none"
+	DefaultPack string `envconfig:"default_pack" default:"classic"`
+	// Seed of the random source generating the hints of the default modules
+	// NOTE: Zero picks a random seed, a fixed seed makes the hints of a game reproducible
+	HintSeed int64 `envconfig:"hint_seed"`
+	// Time a module of a hosted challenge can run for in a single call
+	ModuleTimeout time.Duration `envconfig:"module_timeout" default:"1s"`
+	// Memory a module of a hosted challenge can use in MiB
+	ModuleMemory int `envconfig:"module_memory" default:"16"`
+	// Path of a JSON file describing the scoring rules
+	// NOTE: The default rules are used if it is empty
+	ScoringRules string `envconfig:"scoring_rules"`
 	// Describes the port number to use
 	Port int `envconfing:"port" default:"8080"`
+	// Describes where the game state is stored
+	// Either "memory" or "bolt"
+	Storage string `envconfig:"storage" default:"memory"`
+	// Describes the path of the database file when bolt storage is used
+	StoragePath string `envconfig:"storage_path" default:"centurion.db"`
+	// Time a defender has to provide hints for an attack
+	CombatDefenseTimeout time.Duration `envconfig:"combat_defense_timeout" default:"30s"`
+	// Time an attacker has to provide a solution for the hints
+	CombatAttackTimeout time.Duration `envconfig:"combat_attack_timeout" default:"2m"`
+	// Time a defender has to evaluate a solution
+	CombatEvaluationTimeout time.Duration `envconfig:"combat_evaluation_timeout" default:"30s"`
+	// Interval of checking combats for expired deadlines
+	CombatReaperInterval time.Duration `envconfig:"combat_reaper_interval" default:"5s"`
 }
 
 // Inits configuration
@@ -10,8 +10,10 @@ import (
 	"github.com/riltech/centurion/core/bus"
 	"github.com/riltech/centurion/core/combat"
 	"github.com/riltech/centurion/core/dashboard"
+	"github.com/riltech/centurion/core/game"
 	"github.com/riltech/centurion/core/logger"
 	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
 	"github.com/riltech/centurion/core/scoreboard"
 	"github.com/sirupsen/logrus"
 )
@@ -29,6 +31,9 @@ type Dashboard struct {
 	scoreService  scoreboard.IService
 	combatService combat.IService
 	playerService player.IService
+	gameService   game.IService
+	// Presence ledger of the defenders
+	presenceService presence.IService
 
 	// channels
 
@@ -38,11 +43,25 @@ type Dashboard struct {
 	attackFinishedCh         <-chan *bus.BusEvent
 	defenseModuleInstalledCh <-chan *bus.BusEvent
 	defenseFailedCh          <-chan *bus.BusEvent
+	cheatAttemptCh           <-chan *bus.BusEvent
+	combatTimeoutCh          <-chan *bus.BusEvent
+	gamePhaseChangedCh       <-chan *bus.BusEvent
+	countdownCh              <-chan *bus.BusEvent
+	adminActionCh            <-chan *bus.BusEvent
+	firstBloodCh             <-chan *bus.BusEvent
+	auditFailedCh            <-chan *bus.BusEvent
 }
 
 // Interface check
 var _ IDashboard = (*Dashboard)(nil)
 
+// Keyboard shortcuts of the facilitator to move between game phases
+var phaseKeys = map[string]string{
+	"s": game.PhaseRunning,
+	"p": game.PhasePaused,
+	"f": game.PhaseFinished,
+}
+
 func (d Dashboard) Start() {
 	if err := ui.Init(); err != nil {
 		log.Fatalf("failed to initialize termui: %v", err)
@@ -57,14 +76,14 @@ func (d Dashboard) Start() {
 	welcome.Text = "Placeholder"
 	base := 1.0 / 10
 
-	clockWindow := dashboard.NewClockWindow(d.createdAt)
+	clockWindow := dashboard.NewClockWindow(d.createdAt, d.gameService)
 	eventLog := dashboard.GetEventLog(d.createdAt)
-	uptimeWindow := dashboard.NewUptimeTrackerWindow(d.combatService)
+	uptimeWindow := dashboard.NewUptimeTrackerWindow(d.presenceService)
 	attackerSuccessWindow := dashboard.NewAttackerSuccessWindow(d.combatService)
-	bestDefendersWindow := dashboard.NewBestDefendersWindow(d.playerService)
+	bestDefendersWindow := dashboard.NewBestDefendersWindow(d.playerService, d.combatService)
 	bestAttackersWindow := dashboard.NewBestAttackersWindow(d.playerService)
 	refresh := func() {
-		bestDefendersWindow.Refresh()
+		uptimeWindow.Refresh()
 		attackerSuccessWindow.Refresh()
 		bestAttackersWindow.Refresh()
 		bestDefendersWindow.Refresh()
@@ -85,15 +104,19 @@ func (d Dashboard) Start() {
 			eventLog.List,
 		),
 	)
+	clockWindow.GetWidget().Title = d.gameService.GetPhase()
+	eventLog.Push("[Game] Press 's' to start or resume, 'p' to pause, 'f' to finish and 'q' to quit")
 	logrus.Info("Dashboard is rendering for the first time")
 	ui.Render(grid)
 	termUIEvents := ui.PollEvents()
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			clockWindow.Refresh()
+			// Uptime changes with time even without events
+			uptimeWindow.Refresh()
 			ui.Render(grid)
 			continue
 		case value := <-d.playerRegisteredCh:
@@ -124,6 +147,80 @@ func (d Dashboard) Start() {
 			eventLog.Push(fmt.Sprintf("[Defense] %s failed a defense against %s", event.DefenderName, event.AttackerName))
 			ui.Render(grid)
 			continue
+		case value := <-d.cheatAttemptCh:
+			event, err := value.DecodeCheatAttemptEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			eventLog.Push(fmt.Sprintf("[Cheat] %s tried to cheat on '%s' challenge: %s", event.PlayerName, event.ChallengeName, event.Reason))
+			ui.Render(grid)
+			continue
+		case value := <-d.combatTimeoutCh:
+			event, err := value.DecodeCombatTimeoutEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			stalled := event.DefenderName
+			if event.State == combat.CombatStateAttackFailed {
+				stalled = event.AttackerName
+			}
+			eventLog.Push(fmt.Sprintf("[Timeout] %s did not answer in time on '%s' challenge", stalled, event.ChallengeName))
+			refresh()
+			ui.Render(grid)
+			continue
+		case value := <-d.gamePhaseChangedCh:
+			event, err := value.DecodeGamePhaseChangedEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			clockWindow.GetWidget().Title = event.Phase
+			clockWindow.Refresh()
+			eventLog.Push(fmt.Sprintf("[Game] The game moved from %s to %s", event.Previous, event.Phase))
+			refresh()
+			ui.Render(grid)
+			continue
+		case value := <-d.countdownCh:
+			event, err := value.DecodeCountdownEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			eventLog.Push(fmt.Sprintf("[Game] %s left from the game", event.Remaining))
+			clockWindow.Refresh()
+			ui.Render(grid)
+			continue
+		case value := <-d.adminActionCh:
+			event, err := value.DecodeAdminActionEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			eventLog.Push(fmt.Sprintf("[Admin] %s", event.Description))
+			refresh()
+			ui.Render(grid)
+			continue
+		case value := <-d.firstBloodCh:
+			event, err := value.DecodeFirstBloodEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			eventLog.Push(fmt.Sprintf("[First blood] %s is the first one to crack '%s' challenge", event.AttackerName, event.ChallengeName))
+			ui.Render(grid)
+			continue
+		case value := <-d.auditFailedCh:
+			event, err := value.DecodeAuditFailedEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			eventLog.Push(fmt.Sprintf("[Audit] %s contradicted the example of '%s' challenge, %d combats were re-scored", event.DefenderName, event.ChallengeName, event.RescoredCombats))
+			refresh()
+			ui.Render(grid)
+			continue
 		case value := <-d.attackFinishedCh:
 			event, err := value.DecodeAttackFinishedEvent()
 			if err != nil {
@@ -163,6 +260,12 @@ func (d Dashboard) Start() {
 					logrus.Infoln("Dashboard quits")
 					return
 				}
+				if phase, ok := phaseKeys[e.ID]; ok {
+					if err := d.gameService.SetPhase(phase); err != nil {
+						eventLog.Push(fmt.Sprintf("[Game] %s", err.Error()))
+						ui.Render(grid)
+					}
+				}
 			}
 			continue
 		}
@@ -175,6 +278,8 @@ func NewDashboard(
 	scoreService scoreboard.IService,
 	combatService combat.IService,
 	playerService player.IService,
+	gameService game.IService,
+	presenceService presence.IService,
 ) IDashboard {
 	playerRegisteredCh := eventBus.Listen(bus.EventTypeRegistration)
 	playerJoinedCh := eventBus.Listen(bus.EventTypePlayerJoined)
@@ -182,17 +287,33 @@ func NewDashboard(
 	attackInitiatedCh := eventBus.Listen(bus.EventTypeAttackInitiated)
 	defenseModuleInstalledCh := eventBus.Listen(bus.EventTypeDefenseModuleInstalled)
 	defenseFailedCh := eventBus.Listen(bus.EventTypeDefenseFailed)
+	cheatAttemptCh := eventBus.Listen(bus.EventTypeCheatAttempt)
+	combatTimeoutCh := eventBus.Listen(bus.EventTypeCombatTimeout)
+	gamePhaseChangedCh := eventBus.Listen(bus.EventTypeGamePhaseChanged)
+	countdownCh := eventBus.Listen(bus.EventTypeCountdown)
+	adminActionCh := eventBus.Listen(bus.EventTypeAdminAction)
+	firstBloodCh := eventBus.Listen(bus.EventTypeFirstBlood)
+	auditFailedCh := eventBus.Listen(bus.EventTypeAuditFailed)
 	return Dashboard{
 		createdAt:                time.Now(),
 		bus:                      eventBus,
 		scoreService:             scoreService,
 		combatService:            combatService,
 		playerService:            playerService,
+		gameService:              gameService,
+		presenceService:          presenceService,
 		playerRegisteredCh:       playerRegisteredCh,
 		playerJoinedCh:           playerJoinedCh,
 		attackInitiatedCh:        attackInitiatedCh,
 		attackFinishedCh:         attackFinishedCh,
 		defenseModuleInstalledCh: defenseModuleInstalledCh,
 		defenseFailedCh:          defenseFailedCh,
+		cheatAttemptCh:           cheatAttemptCh,
+		combatTimeoutCh:          combatTimeoutCh,
+		gamePhaseChangedCh:       gamePhaseChangedCh,
+		countdownCh:              countdownCh,
+		adminActionCh:            adminActionCh,
+		firstBloodCh:             firstBloodCh,
+		auditFailedCh:            auditFailedCh,
 	}
 }
@@ -12,12 +12,17 @@ func nice(n int) string {
 	return fmt.Sprintf("%d", n)
 }
 
-func GetTimePassedSince(createdAt time.Time, withSeconds bool) string {
-	seconds := int(time.Now().Sub(createdAt).Seconds()) % 60
-	minutes := int(time.Now().Sub(createdAt).Minutes())
-	hours := int(minutes / 60)
+// Formats a given duration as hours and minutes (and seconds)
+func FormatDuration(d time.Duration, withSeconds bool) string {
+	seconds := int(d.Seconds()) % 60
+	minutes := int(d.Minutes()) % 60
+	hours := int(d.Hours())
 	if !withSeconds {
 		return fmt.Sprintf("%s:%s", nice(hours), nice(minutes))
 	}
 	return fmt.Sprintf("[%s:%s:%s]", nice(hours), nice(minutes), nice(seconds))
 }
+
+func GetTimePassedSince(createdAt time.Time, withSeconds bool) string {
+	return FormatDuration(time.Since(createdAt), withSeconds)
+}
@@ -8,7 +8,9 @@ import (
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
 	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/game"
 	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
 )
 
 // Header component for the dashboard
@@ -65,21 +67,33 @@ func GetEventLog(createdAt time.Time) *LogWindow {
 }
 
 // Describes a clock window widget
+// Shows the time remaining from the game if it has a duration
+// otherwise the time passed since the start
 type ClockWindow struct {
-	createdAt time.Time
-	widget    *widgets.Paragraph
+	createdAt   time.Time
+	gameService game.IService
+	widget      *widgets.Paragraph
 }
 
 // Interface check
 var _ IRefreshable = (*ClockWindow)(nil)
 
 // Constructor for ClockWindow
-func NewClockWindow(createdAt time.Time) *ClockWindow {
+func NewClockWindow(createdAt time.Time, gameService game.IService) *ClockWindow {
 	return &ClockWindow{
-		createdAt: createdAt,
+		createdAt:   createdAt,
+		gameService: gameService,
 	}
 }
 
+// Returns the text to show on the clock
+func (cw *ClockWindow) getText() string {
+	if remaining, ok := cw.gameService.GetRemaining(); ok {
+		return fmt.Sprintf("%s left", FormatDuration(remaining, false))
+	}
+	return GetTimePassedSince(cw.createdAt, false)
+}
+
 // returns the widget
 func (cw *ClockWindow) GetWidget() *widgets.Paragraph {
 	if cw == nil {
@@ -87,7 +101,7 @@ func (cw *ClockWindow) GetWidget() *widgets.Paragraph {
 	}
 	if cw.widget == nil {
 		clock := widgets.NewParagraph()
-		clock.Text = GetTimePassedSince(cw.createdAt, false)
+		clock.Text = cw.getText()
 		clock.BorderStyle.Fg = ui.ColorYellow
 		clock.TextStyle.Modifier = ui.ModifierBold
 		cw.widget = clock
@@ -97,7 +111,7 @@ func (cw *ClockWindow) GetWidget() *widgets.Paragraph {
 
 // Refreshes the time on the clock
 func (cw *ClockWindow) Refresh() {
-	cw.widget.Text = GetTimePassedSince(cw.createdAt, false)
+	cw.widget.Text = cw.getText()
 }
 
 // Describes a gauge component
@@ -109,7 +123,7 @@ type GaugeComponent struct {
 // Tracks overall uptime for the defensive team
 type UptimeTrackerWindow struct {
 	GaugeComponent
-	service combat.IService
+	service presence.IService
 }
 
 // Interface check
@@ -131,14 +145,19 @@ func (utw *UptimeTrackerWindow) Refresh() {
 	if utw == nil {
 		return
 	}
-	utw.Gauge.Percent = utw.service.GetDefenseFailPercent()
+	uptime, ok := utw.service.GetTeamUptime()
+	if !ok {
+		// Nobody to track before the first installed challenge
+		uptime = 100
+	}
+	utw.Gauge.Percent = uptime
 }
 
 // Constructor for an UptimeTrackerWindow
-func NewUptimeTrackerWindow(combatService combat.IService) *UptimeTrackerWindow {
+func NewUptimeTrackerWindow(presenceService presence.IService) *UptimeTrackerWindow {
 	return &UptimeTrackerWindow{
 		GaugeComponent: GaugeComponent{100, nil},
-		service:        combatService,
+		service:        presenceService,
 	}
 }
 
@@ -231,9 +250,11 @@ func NewBestAttackersWindow(playerService player.IService) *BestAttackersWindow
 }
 
 // Tracks the top 5 defenders
+// NOTE: Defenders who failed a determinism audit are flagged
 type BestDefendersWindow struct {
 	BestPlayersWindow
 	playerService player.IService
+	combatService combat.IService
 }
 
 // Interface check
@@ -262,9 +283,14 @@ func (bdw *BestDefendersWindow) Refresh() {
 	sort.Slice(players, func(i, j int) bool {
 		return players[i].Score > players[j].Score
 	})
+	audits := bdw.combatService.GetAuditResults()
 	selectedNames := []string{}
 	for _, p := range players {
-		selectedNames = append(selectedNames, fmt.Sprintf("%s - %d", p.Name, p.Score))
+		row := fmt.Sprintf("%s - %d", p.Name, p.Score)
+		if result := audits[p.ID]; result.IsFlagged() {
+			row = fmt.Sprintf("%s [failed %d/%d audits]", row, result.Failed, result.GetTotal())
+		}
+		selectedNames = append(selectedNames, row)
 	}
 	if len(selectedNames) == 0 {
 		selectedNames = []string{"No defenders yet"}
@@ -273,8 +299,9 @@ func (bdw *BestDefendersWindow) Refresh() {
 }
 
 // Constructor for a new best attackers window
-func NewBestDefendersWindow(playerService player.IService) *BestDefendersWindow {
+func NewBestDefendersWindow(playerService player.IService, combatService combat.IService) *BestDefendersWindow {
 	return &BestDefendersWindow{
 		playerService: playerService,
+		combatService: combatService,
 	}
 }
@@ -7,12 +7,16 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/riltech/centurion/core/auth"
 	"github.com/riltech/centurion/core/bus"
 	"github.com/riltech/centurion/core/challenge"
 	"github.com/riltech/centurion/core/combat"
 	"github.com/riltech/centurion/core/engine"
+	"github.com/riltech/centurion/core/game"
 	"github.com/riltech/centurion/core/logger"
 	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/sandbox"
 	"github.com/riltech/centurion/core/scoreboard"
 	"github.com/sirupsen/logrus"
 )
@@ -36,8 +40,9 @@ type Engine struct {
 
 	// Internal dependencies
 
-	ctrl    engine.IConroller
-	service engine.IService
+	ctrl        engine.IConroller
+	service     engine.IService
+	gameService game.IService
 }
 
 // Interface check
@@ -54,6 +59,8 @@ func (e *Engine) Start() {
 		WriteTimeout: 25 * time.Second,
 		ReadTimeout:  25 * time.Second,
 	}
+	e.gameService.StartClock()
+	e.service.Start()
 	logrus.Infof("Engine starts listening on %d", e.port)
 	if err := e.server.ListenAndServe(); err != nil {
 		logger.LogError(err)
@@ -61,7 +68,14 @@ func (e *Engine) Start() {
 }
 
 func (e Engine) Stop() {
+	e.gameService.StopClock()
+	if e.gameService.GetPhase() != game.PhaseFinished {
+		if err := e.gameService.SetPhase(game.PhaseFinished); err != nil {
+			logger.LogError(err)
+		}
+	}
 	e.FinishGame()
+	e.service.Stop()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := e.server.Shutdown(ctx); err != nil {
@@ -80,23 +94,29 @@ func NewEngine(
 	scoreService scoreboard.IService,
 	combatService combat.IService,
 	playerService player.IService,
+	challengeService challenge.IService,
+	gameService game.IService,
+	presenceService presence.IService,
+	sandboxService sandbox.IService,
+	authService auth.IService,
+	adminToken string,
+	settings engine.Settings,
 ) IEngine {
-	challengeRepo := challenge.NewRepository()
-	challengeService := challenge.NewService(challengeRepo)
 	err := challengeService.AddDefaultModules()
 	if err != nil {
 		logrus.Fatal(err)
 	}
-	engineService := engine.NewService(bus, playerService, challengeService, combatService, scoreService)
+	engineService := engine.NewService(bus, playerService, challengeService, combatService, scoreService, gameService, presenceService, sandboxService, settings)
 	return &Engine{
 		// Available after start is called
 		router: nil,
 		server: nil,
 
 		// Available as the instance is created
-		port:    port,
-		bus:     bus,
-		ctrl:    engine.NewController(bus, engineService, playerService, challengeService, scoreService),
-		service: engineService,
+		port:        port,
+		bus:         bus,
+		ctrl:        engine.NewController(bus, engineService, playerService, challengeService, combatService, scoreService, gameService, presenceService, authService, adminToken),
+		service:     engineService,
+		gameService: gameService,
 	}
 }
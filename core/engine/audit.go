@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/logger"
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/sirupsen/logrus"
+)
+
+// Determinism audits replay the example of a published challenge
+// to its defender in a regular solution evaluation request at random times
+// The defender has to accept it, rejecting its own example (or not answering in time)
+// fails the audit which costs a penalty and re-scores the solutions
+// the defender rejected on the challenge
+
+func (s *Service) AuditChallenge(ID string) error {
+	target, err := s.challengeService.FindByID(ID)
+	if err != nil {
+		return err
+	}
+	if target.Type == challenge.ChallengeTypeDefault || !target.IsPublished() || s.isServedByModule(target) {
+		return fmt.Errorf("%s challenge cannot be audited", ID)
+	}
+	if !s.isConnected(target.CreatorID) {
+		return fmt.Errorf("%s is not connected", target.CreatorID)
+	}
+	// Only one probe of a challenge runs at a time
+	if _, err = s.combatService.FindByAttackerAndChallenge(combat.SystemAttackerID, target.ID); err == nil {
+		return nil
+	}
+	probe := combat.Model{
+		ID:               uuid.NewString(),
+		Type:             combat.CombatTypeAudit,
+		ChallengeID:      target.ID,
+		ChallengeVersion: target.Version,
+		AttackerID:       combat.SystemAttackerID,
+		DefenderID:       target.CreatorID,
+		CombatState:      combat.CombatStateAttackInitiated,
+	}
+	if err = s.combatService.AddCombat(probe); err != nil {
+		return err
+	}
+	if _, err = s.combatService.SetIssuedHints(probe.ID, target.Example.Hints); err != nil {
+		logger.LogError(err)
+	}
+	s.requestProbeEvaluation(probe, target.Example.Hints, target.Example.Solutions)
+	return nil
+}
+
+// Starts auditing the connected defenders periodically in the background
+func (s *Service) startAuditor() {
+	if s.settings.AuditInterval <= 0 || s.settings.AuditChance <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.settings.AuditInterval)
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopAuditor:
+				return
+			case <-ticker.C:
+				// Defenders only evaluate solutions while the game is running
+				if err := s.gameService.CanAttack(); err != nil {
+					continue
+				}
+				for _, target := range s.getAuditTargets(random) {
+					if err := s.AuditChallenge(target.ID); err != nil {
+						logger.LogError(err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Picks a random published challenge of every connected defender
+// with the chance configured in the settings
+func (s *Service) getAuditTargets(random *rand.Rand) []challenge.Model {
+	byCreator := map[string][]challenge.Model{}
+	for _, c := range s.challengeService.GetChallenges() {
+		if c.Type == challenge.ChallengeTypeDefault || !c.IsPublished() || !s.isConnected(c.CreatorID) || s.isServedByModule(c) {
+			continue
+		}
+		byCreator[c.CreatorID] = append(byCreator[c.CreatorID], c)
+	}
+	targets := []challenge.Model{}
+	for _, challenges := range byCreator {
+		if random.Float64() >= s.settings.AuditChance {
+			continue
+		}
+		targets = append(targets, challenges[random.Intn(len(challenges))])
+	}
+	return targets
+}
+
+// Handles the evaluation of the defender in an audit probe
+func (s *Service) handleAuditEvaluation(m combat.Model, success bool) {
+	if m.CombatState != combat.CombatStateSolutionEvaluationRequested {
+		s.sendError(m.DefenderID, "Evaluation is not expected for the combat")
+		return
+	}
+	state := combat.CombatStateDefenseSucceeded
+	// An update of the challenge in the meantime could change its example
+	if current, err := s.challengeService.FindByID(m.ChallengeID); !success && err == nil && current.Version == m.ChallengeVersion {
+		state = combat.CombatStateDefenseFailed
+	}
+	updated, err := s.combatService.UpdateCombatState(m.ID, state)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	if updated.CombatState == combat.CombatStateDefenseSucceeded {
+		logrus.Infof("%s passed the audit of %s", m.DefenderID, m.ChallengeID)
+		return
+	}
+	s.failAudit(updated)
+}
+
+// Penalizes a defender who failed an audit
+// and re-scores the solutions rejected on the audited challenge
+func (s *Service) failAudit(m combat.Model) {
+	logrus.Warnf("%s failed the audit of %s", m.DefenderID, m.ChallengeID)
+	defender, err := s.playerService.FindByID(m.DefenderID)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	target, err := s.challengeService.FindByID(m.ChallengeID)
+	if err != nil {
+		// The challenge was removed in the meantime
+		target = challenge.Model{ID: m.ChallengeID}
+	}
+	if err = s.scoreService.AddPoint(defender.ID, -s.scoreService.GetRules().Defender.AuditPenalty, scoreboard.Reason{
+		Code:        scoreboard.ReasonCodeAuditFailed,
+		CombatID:    m.ID,
+		ChallengeID: m.ChallengeID,
+		Message:     "Contradicted the example of the challenge",
+	}); err != nil {
+		logger.LogError(err)
+	}
+	rescored := s.rescoreRejectedSolutions(target, m.ChallengeVersion)
+	s.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeAuditFailed,
+		Information: bus.AuditFailedEvent{
+			DefenderName:    defender.Name,
+			ChallengeName:   target.Name,
+			RescoredCombats: rescored,
+		},
+	})
+}
+
+// Scores the solutions rejected on a given version of a challenge
+// as if the defender failed to defend them
+// NOTE: Only the audited version is contradicted by its example,
+// every combat is re-scored only once, returns the number of re-scored combats
+func (s *Service) rescoreRejectedSolutions(target challenge.Model, version int) int {
+	rules := s.scoreService.GetRules()
+	rescored := map[string]bool{}
+	for _, team := range []string{player.TeamTypeAttacker, player.TeamTypeDefender} {
+		for _, entry := range s.scoreService.GetTeamLedger(team) {
+			if entry.Reason.Code == scoreboard.ReasonCodeAuditRescore {
+				rescored[entry.Reason.CombatID] = true
+			}
+		}
+	}
+	count := 0
+	for _, c := range s.combatService.GetRejectedSolutions(target.ID) {
+		if rescored[c.ID] || c.IsAgainstSystem() || c.ServedByModule || c.ChallengeVersion != version {
+			continue
+		}
+		reason := scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeAuditRescore,
+			CombatID:    c.ID,
+			ChallengeID: c.ChallengeID,
+			Message:     "Solution was rejected by a defender who failed an audit",
+		}
+		// The defense flow of the rejected solution is taken back
+		// as it was given on the version the combat was fought on
+		fought, err := s.challengeService.FindVersion(c.ChallengeID, c.ChallengeVersion)
+		if err != nil {
+			fought = target
+		}
+		if err = s.scoreService.AddPoint(c.DefenderID, -rules.Scale(rules.Defender.DefenseFlow, fought.Difficulty), reason); err != nil {
+			logger.LogError(err)
+		}
+		if err := s.scoreService.AddPoint(c.AttackerID, rules.Attacker.DefenderFailed, reason); err != nil {
+			logger.LogError(err)
+		}
+		count++
+	}
+	return count
+}
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,11 +11,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
+	"github.com/riltech/centurion/core/auth"
 	"github.com/riltech/centurion/core/bus"
 	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
 	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/game"
 	"github.com/riltech/centurion/core/logger"
 	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
 	"github.com/riltech/centurion/core/scoreboard"
 )
 
@@ -26,10 +31,36 @@ type IConroller interface {
 	FetchChallanges(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	// Endpoint for installing defense modules
 	InstallChallenge(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	// Endpoint for updating a defense module by its creator
+	UpdateChallenge(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+	// Endpoint for fetching which attackers solved which challenges
+	FetchCompletions(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	// Endpoint for fetching the uptime of the defenders
+	FetchUptime(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	// Endpoint for fetching the score breakdown of a player
+	FetchPlayerScore(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+	// Endpoint for fetching the score ledger of a team
+	FetchTeamScore(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 	// Endpoint for registration
 	Register(http.ResponseWriter, *http.Request, httprouter.Params)
 	// Entry point for the websocket API
 	PlayerJoin(w http.ResponseWriter, r *http.Request)
+	// Admin endpoint for listing players with their online state
+	AdminListPlayers(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	// Admin endpoint for disconnecting a player
+	AdminKickPlayer(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+	// Admin endpoint for removing a challenge
+	AdminRemoveChallenge(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+	// Admin endpoint for disabling, retiring or publishing a challenge again
+	AdminSetChallengeStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+	// Admin endpoint for adjusting the score of a player or a team
+	AdminAdjustScore(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	// Admin endpoint for ending a combat
+	AdminEndCombat(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+	// Admin endpoint for moving the game into a given phase
+	AdminSetPhase(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
+	// Admin endpoint for finishing the game
+	AdminFinishGame(w http.ResponseWriter, r *http.Request, _ httprouter.Params)
 	// Boostrapping of the router
 	GetRouter() *httprouter.Router
 }
@@ -40,10 +71,17 @@ type Controller struct {
 	engineService    IService
 	playerService    player.IService
 	challengeService challenge.IService
+	combatService    combat.IService
 	scoreService     scoreboard.IService
+	gameService      game.IService
+	presenceService  presence.IService
+	authService      auth.IService
 
 	// Websocket
 	upgrader websocket.Upgrader
+
+	// Token required by the admin endpoints
+	adminToken string
 }
 
 // Constructor for the engine controller
@@ -52,15 +90,25 @@ func NewController(
 	engineService IService,
 	playerService player.IService,
 	challengeService challenge.IService,
+	combatService combat.IService,
 	scoreService scoreboard.IService,
+	gameService game.IService,
+	presenceService presence.IService,
+	authService auth.IService,
+	adminToken string,
 ) IConroller {
 	return &Controller{
 		bus,
 		engineService,
 		playerService,
 		challengeService,
+		combatService,
 		scoreService,
+		gameService,
+		presenceService,
+		authService,
 		websocket.Upgrader{},
+		adminToken,
 	}
 }
 
@@ -79,16 +127,23 @@ func (c Controller) FetchChallanges(w http.ResponseWriter, r *http.Request, _ ht
 	var response *ResponseCreator
 	defer c.cleanUp(w)
 	challenges := c.challengeService.GetChallenges()
+	numberOfAttackers := len(c.playerService.GetTeam(player.TeamTypeAttacker))
+	completions := c.combatService.GetCompletionMatrix()
 	dtoChallenges := []*dto.ChallengeResponseDTO{}
 	for _, challenge := range challenges {
+		solvers := completions.GetSolverCount(challenge.ID)
 		dtoChallenges = append(dtoChallenges, &dto.ChallengeResponseDTO{
 			ID:          challenge.ID,
 			Name:        challenge.Name,
 			Description: challenge.Description,
+			Difficulty:  challenge.Difficulty,
 			Example: dto.ChallengeExampleDTO{
 				Hints:     challenge.Example.Hints,
 				Solutions: challenge.Example.Solutions,
 			},
+			Version:   challenge.Version,
+			Solvers:   solvers,
+			SolveRate: getSolveRate(solvers, numberOfAttackers),
 		})
 	}
 	response.OK(w, dto.FetchChallengesResponse{
@@ -119,6 +174,12 @@ func (c Controller) Register(w http.ResponseWriter, r *http.Request, _ httproute
 		})
 		return
 	}
+	if err = c.gameService.CanJoin(); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
 	if reqDTO.Name == "" {
 		response.BadRequest(w, map[string]interface{}{
 			"reason": "Name is required",
@@ -163,7 +224,8 @@ func (c Controller) Register(w http.ResponseWriter, r *http.Request, _ httproute
 			Code:    200,
 			Meta:    nil,
 		},
-		ID: information.ID,
+		ID:    information.ID,
+		Token: c.authService.Issue(information.ID),
 	})
 }
 
@@ -182,9 +244,33 @@ func (c Controller) PlayerJoin(w http.ResponseWriter, r *http.Request) {
 	var join dto.JoinEvent
 	if err = json.Unmarshal(message, &join); err != nil {
 		logger.LogError(err)
+		c.rejectJoin(connection, dto.CloseCodeInvalidCredentials, "Join event is malformed")
+		return
+	}
+	playerID, err := c.authService.Verify(join.Token)
+	if err != nil || (join.ID != "" && join.ID != playerID) {
+		c.rejectJoin(connection, dto.CloseCodeInvalidCredentials, "Invalid credentials")
 		return
 	}
-	c.engineService.Join(join, connection)
+	join.ID = playerID
+	if err = c.engineService.Join(join, connection); err != nil {
+		logger.LogError(err)
+		code := dto.CloseCodeInvalidCredentials
+		if err == ErrSessionAlreadyActive {
+			code = dto.CloseCodeSessionAlreadyActive
+		}
+		c.rejectJoin(connection, code, err.Error())
+	}
+}
+
+// Closes a websocket connection which could not join the game
+func (c Controller) rejectJoin(connection *websocket.Conn, code int, reason string) {
+	err := connection.WriteMessage(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason))
+	if err != nil {
+		logger.LogError(err)
+	}
 }
 
 func (c Controller) InstallChallenge(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -204,6 +290,18 @@ func (c Controller) InstallChallenge(w http.ResponseWriter, r *http.Request, _ h
 		})
 		return
 	}
+	if err = c.gameService.CanJoin(); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	defenderID, err := c.authService.Verify(reqDTO.Token)
+	if err != nil || (reqDTO.DefenderID != "" && reqDTO.DefenderID != defenderID) {
+		response.Unauthorized(w)
+		return
+	}
+	reqDTO.DefenderID = defenderID
 	defender, err := c.playerService.FindByID(reqDTO.DefenderID)
 	if err != nil || defender.Team != player.TeamTypeDefender {
 		response.BadRequest(w, map[string]interface{}{
@@ -211,18 +309,38 @@ func (c Controller) InstallChallenge(w http.ResponseWriter, r *http.Request, _ h
 		})
 		return
 	}
+	if reqDTO.Difficulty == "" {
+		reqDTO.Difficulty = challenge.DifficultyMedium
+	}
+	if !challenge.IsValidDifficulty(reqDTO.Difficulty) {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": "Difficulty has to be easy, medium or hard",
+		})
+		return
+	}
+	if len(reqDTO.Module) > 0 {
+		if err = c.engineService.ValidateModule(reqDTO.Module); err != nil {
+			response.BadRequest(w, map[string]interface{}{
+				"reason": err.Error(),
+			})
+			return
+		}
+	}
 	toCreate := challenge.Model{
 		Type:        challenge.ChallengeTypePlayerCreated,
 		ID:          uuid.NewString(),
 		CreatorID:   reqDTO.DefenderID,
 		Name:        reqDTO.Name,
 		Description: reqDTO.Description,
+		Difficulty:  reqDTO.Difficulty,
+		Status:      challenge.ChallengeStatusPendingVerification,
 		Example: challenge.Example{
 			Hints:     reqDTO.Example.Hints,
 			Solutions: reqDTO.Example.Solutions,
 		},
+		Module:         reqDTO.Module,
+		LiveEvaluation: reqDTO.LiveEvaluation,
 	}
-	isFirstModule := c.challengeService.IsFirstModule(toCreate)
 	err = c.challengeService.AddChallenge(toCreate)
 	if err != nil {
 		response.BadRequest(w, map[string]interface{}{
@@ -230,28 +348,269 @@ func (c Controller) InstallChallenge(w http.ResponseWriter, r *http.Request, _ h
 		})
 		return
 	}
-	if isFirstModule {
-		if err = c.scoreService.AddPoint(reqDTO.DefenderID, 1); err != nil {
-			logger.LogError(err)
+	response.OK(w, dto.InstallChallengeResponse{
+		CenturionResponse: dto.CenturionResponse{
+			Message: "Success",
+			Code:    200,
+			Meta:    nil,
+		},
+		ID:     toCreate.ID,
+		Status: toCreate.Status,
+	})
+	// The challenge is published once the defender passes the verification
+	// NOTE: It starts after the response so the defender knows the ID already
+	if err = c.engineService.VerifyChallenge(toCreate.ID); err != nil {
+		logger.LogError(err)
+	}
+}
+
+// Handles PUT /challenges/:id request
+func (c Controller) UpdateChallenge(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": "Body is malformed",
+		})
+		return
+	}
+	var reqDTO dto.UpdateChallengeRequest
+	if err = json.Unmarshal(b, &reqDTO); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": "Body is malformed",
+		})
+		return
+	}
+	if err = c.gameService.CanJoin(); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	defenderID, err := c.authService.Verify(reqDTO.Token)
+	if err != nil {
+		response.Unauthorized(w)
+		return
+	}
+	target, err := c.challengeService.FindByID(ps.ByName("id"))
+	if err != nil {
+		response.NotFound(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	if target.CreatorID != defenderID {
+		response.Unauthorized(w)
+		return
+	}
+	// Pulled challenges and challenges under verification cannot change
+	if !target.IsPublished() {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": getUnavailableReason(target),
+		})
+		return
+	}
+	if reqDTO.Difficulty != "" && !challenge.IsValidDifficulty(reqDTO.Difficulty) {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": "Difficulty has to be easy, medium or hard",
+		})
+		return
+	}
+	if len(reqDTO.Module) > 0 {
+		if err = c.engineService.ValidateModule(reqDTO.Module); err != nil {
+			response.BadRequest(w, map[string]interface{}{
+				"reason": err.Error(),
+			})
+			return
+		}
+	}
+	toUpdate := target
+	if reqDTO.Description != "" {
+		toUpdate.Description = reqDTO.Description
+	}
+	if reqDTO.Difficulty != "" {
+		toUpdate.Difficulty = reqDTO.Difficulty
+	}
+	if reqDTO.Example.Hints != nil || reqDTO.Example.Solutions != nil {
+		toUpdate.Example = challenge.Example{
+			Hints:     reqDTO.Example.Hints,
+			Solutions: reqDTO.Example.Solutions,
+		}
+	}
+	if len(reqDTO.Module) > 0 {
+		toUpdate.Module = reqDTO.Module
+	}
+	if reqDTO.LiveEvaluation != nil {
+		toUpdate.LiveEvaluation = *reqDTO.LiveEvaluation
+	}
+	// A new example or module is verified the same way as a new challenge
+	needsVerification := !isSameHints(target.Example.Hints, toUpdate.Example.Hints) ||
+		!isSameHints(target.Example.Solutions, toUpdate.Example.Solutions) ||
+		!bytes.Equal(target.Module, toUpdate.Module)
+	// NOTE: The current version stays published until the new one is verified
+	update := c.challengeService.UpdateChallenge
+	if needsVerification {
+		update = c.challengeService.DraftChallenge
+	}
+	updated, err := update(toUpdate)
+	if err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	response.OK(w, dto.UpdateChallengeResponse{
+		CenturionResponse: dto.CenturionResponse{
+			Message: "Success",
+			Code:    200,
+			Meta:    nil,
+		},
+		ID:      updated.ID,
+		Version: updated.Version,
+		Status:  updated.Status,
+	})
+	if !needsVerification {
+		return
+	}
+	if err = c.engineService.VerifyChallenge(updated.ID); err != nil {
+		logger.LogError(err)
+	}
+}
+
+func (c Controller) FetchCompletions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	numberOfAttackers := len(c.playerService.GetTeam(player.TeamTypeAttacker))
+	completions := c.combatService.GetCompletionMatrix()
+	challenges := []dto.ChallengeCompletionDTO{}
+	for _, ch := range c.challengeService.GetChallenges() {
+		challenges = append(challenges, dto.ChallengeCompletionDTO{
+			ID:      ch.ID,
+			Name:    ch.Name,
+			Solvers: completions.GetSolvers(ch.ID),
+			Percent: getSolveRate(completions.GetSolverCount(ch.ID), numberOfAttackers),
+		})
+	}
+	response.OK(w, dto.CompletionResponse{
+		CenturionResponse: dto.CenturionResponse{
+			Message: "Success",
+			Code:    200,
+			Meta:    nil,
+		},
+		Attackers:  numberOfAttackers,
+		Challenges: challenges,
+	})
+}
+
+func (c Controller) FetchUptime(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	teamUptime, _ := c.presenceService.GetTeamUptime()
+	uptimes := c.presenceService.GetUptimes()
+	defenders := []dto.DefenderUptimeDTO{}
+	for _, defender := range c.playerService.GetTeam(player.TeamTypeDefender) {
+		uptime, ok := uptimes[defender.ID]
+		if !ok {
+			// Did not install a challenge yet
+			continue
 		}
+		defenders = append(defenders, dto.DefenderUptimeDTO{
+			ID:     defender.ID,
+			Name:   defender.Name,
+			Online: defender.Online,
+			Uptime: uptime,
+		})
 	}
-	c.bus.Send(&bus.BusEvent{
-		Type: bus.EventTypeDefenseModuleInstalled,
-		Information: bus.DefenseModuleInstalledEvent{
-			Name:        reqDTO.Name,
-			CreatorName: defender.Name,
+	response.OK(w, dto.UptimeResponse{
+		CenturionResponse: dto.CenturionResponse{
+			Message: "Success",
+			Code:    200,
+			Meta:    nil,
 		},
+		Team:      teamUptime,
+		Defenders: defenders,
 	})
-	response.OK(w, dto.InstallChallengeResponse{
+}
+
+// Handles GET /scores/players/:id request
+func (c Controller) FetchPlayerScore(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	p, err := c.playerService.FindByID(ps.ByName("id"))
+	if err != nil {
+		response.NotFound(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	total, entries := toScoreEntryDTOs(c.scoreService.GetPlayerLedger(p.ID))
+	response.OK(w, dto.PlayerScoreResponse{
 		CenturionResponse: dto.CenturionResponse{
 			Message: "Success",
 			Code:    200,
 			Meta:    nil,
 		},
-		ID: toCreate.ID,
+		ID:      p.ID,
+		Name:    p.Name,
+		Team:    p.Team,
+		Total:   total,
+		Entries: entries,
 	})
 }
 
+// Handles GET /scores/teams/:team request
+func (c Controller) FetchTeamScore(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	team := strings.ToLower(ps.ByName("team"))
+	if team != player.TeamTypeAttacker && team != player.TeamTypeDefender {
+		response.NotFound(w, map[string]interface{}{
+			"reason": fmt.Sprintf("%s is not a valid team", team),
+		})
+		return
+	}
+	total, entries := toScoreEntryDTOs(c.scoreService.GetTeamLedger(team))
+	response.OK(w, dto.TeamScoreResponse{
+		CenturionResponse: dto.CenturionResponse{
+			Message: "Success",
+			Code:    200,
+			Meta:    nil,
+		},
+		Team:    team,
+		Total:   total,
+		Entries: entries,
+	})
+}
+
+// Returns the percentage of the attackers who solved a challenge
+func getSolveRate(solvers int, numberOfAttackers int) int {
+	if numberOfAttackers == 0 {
+		return 0
+	}
+	return solvers * 100 / numberOfAttackers
+}
+
+// Converts ledger entries to DTOs and returns their total
+func toScoreEntryDTOs(ledger []scoreboard.Entry) (int, []dto.ScoreEntryDTO) {
+	total := 0
+	entries := make([]dto.ScoreEntryDTO, 0, len(ledger))
+	for _, entry := range ledger {
+		total += entry.Delta
+		entries = append(entries, dto.ScoreEntryDTO{
+			At:          entry.At,
+			PlayerID:    entry.PlayerID,
+			Team:        entry.Team,
+			Delta:       entry.Delta,
+			Code:        entry.Reason.Code,
+			CombatID:    entry.Reason.CombatID,
+			ChallengeID: entry.Reason.ChallengeID,
+			Message:     entry.Reason.Message,
+		})
+	}
+	return total, entries
+}
+
 func (c Controller) Ping(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	(*ResponseCreator)(nil).Empty200(w)
 }
@@ -263,6 +622,19 @@ func (c Controller) GetRouter() *httprouter.Router {
 	router.POST("/team/register", c.Register)
 	router.GET("/challenges", c.FetchChallanges)
 	router.POST("/challenges", c.InstallChallenge)
+	router.PUT("/challenges/:id", c.UpdateChallenge)
+	router.GET("/uptime", c.FetchUptime)
+	router.GET("/completions", c.FetchCompletions)
+	router.GET("/scores/players/:id", c.FetchPlayerScore)
+	router.GET("/scores/teams/:team", c.FetchTeamScore)
 	router.HandlerFunc("GET", "/team/join", c.PlayerJoin)
+	router.GET("/admin/players", c.admin(c.AdminListPlayers))
+	router.POST("/admin/players/:id/kick", c.admin(c.AdminKickPlayer))
+	router.DELETE("/admin/challenges/:id", c.admin(c.AdminRemoveChallenge))
+	router.POST("/admin/challenges/:id/status", c.admin(c.AdminSetChallengeStatus))
+	router.POST("/admin/scores", c.admin(c.AdminAdjustScore))
+	router.POST("/admin/combats/:id/end", c.admin(c.AdminEndCombat))
+	router.POST("/admin/game/phase", c.admin(c.AdminSetPhase))
+	router.POST("/admin/game/finish", c.admin(c.AdminFinishGame))
 	return router
 }
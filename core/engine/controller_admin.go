@@ -0,0 +1,265 @@
+package engine
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/game"
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/scoreboard"
+)
+
+// Wraps a handler to be only accessible with the admin token
+// NOTE: Admin endpoints are disabled if no token is configured
+func (c Controller) admin(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if c.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.adminToken)) != 1 {
+			(*ResponseCreator)(nil).Unauthorized(w)
+			return
+		}
+		handle(w, r, ps)
+	}
+}
+
+// Publishes an admin action on the bus
+func (c Controller) publishAdminAction(action string, description string) {
+	c.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeAdminAction,
+		Information: bus.AdminActionEvent{
+			Action:      action,
+			Description: description,
+		},
+	})
+}
+
+// Reads the request body into a given DTO
+// returns false if the body is malformed
+func (c Controller) readBody(w http.ResponseWriter, r *http.Request, reqDTO interface{}) bool {
+	b, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(b, reqDTO)
+	}
+	if err != nil {
+		(*ResponseCreator)(nil).BadRequest(w, map[string]interface{}{
+			"reason": "Body is malformed",
+		})
+		return false
+	}
+	return true
+}
+
+// Handles GET /admin/players request
+func (c Controller) AdminListPlayers(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	players := []*dto.AdminPlayerDTO{}
+	for _, team := range []string{player.TeamTypeAttacker, player.TeamTypeDefender} {
+		for _, p := range c.playerService.GetTeam(team) {
+			players = append(players, &dto.AdminPlayerDTO{
+				ID:     p.ID,
+				Name:   p.Name,
+				Team:   p.Team,
+				Score:  p.Score,
+				Online: p.Online,
+			})
+		}
+	}
+	response.OK(w, dto.AdminPlayersResponse{
+		CenturionResponse: dto.CenturionResponse{
+			Message: "Success",
+			Code:    200,
+			Meta:    nil,
+		},
+		Players: players,
+	})
+}
+
+// Handles POST /admin/players/:id/kick request
+func (c Controller) AdminKickPlayer(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	p, err := c.playerService.FindByID(ps.ByName("id"))
+	if err != nil {
+		response.NotFound(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	if err = c.engineService.Kick(p.ID); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	c.publishAdminAction("kick", fmt.Sprintf("%s was disconnected", p.Name))
+	response.Empty200(w)
+}
+
+// Handles DELETE /admin/challenges/:id request
+func (c Controller) AdminRemoveChallenge(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	target, err := c.challengeService.FindByID(ps.ByName("id"))
+	if err != nil {
+		response.NotFound(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	if err = c.challengeService.RemoveChallenge(target.ID); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	c.publishAdminAction("remove_challenge", fmt.Sprintf("'%s' challenge was removed", target.Name))
+	response.Empty200(w)
+}
+
+// Handles POST /admin/challenges/:id/status request
+func (c Controller) AdminSetChallengeStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	var reqDTO dto.AdminChallengeStatusRequest
+	if !c.readBody(w, r, &reqDTO) {
+		return
+	}
+	switch reqDTO.Status {
+	case challenge.ChallengeStatusPublished, challenge.ChallengeStatusDisabled, challenge.ChallengeStatusRetired:
+	default:
+		response.BadRequest(w, map[string]interface{}{
+			"reason": "Status has to be published, disabled or retired",
+		})
+		return
+	}
+	target, err := c.challengeService.FindByID(ps.ByName("id"))
+	if err != nil {
+		response.NotFound(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	// Challenges are only published by passing the verification
+	if target.Status == challenge.ChallengeStatusPendingVerification {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": getUnavailableReason(target),
+		})
+		return
+	}
+	if _, err = c.challengeService.SetStatus(target.ID, reqDTO.Status); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	c.publishAdminAction("set_challenge_status", fmt.Sprintf("'%s' challenge is %s", target.Name, reqDTO.Status))
+	response.Empty200(w)
+}
+
+// Handles POST /admin/scores request
+func (c Controller) AdminAdjustScore(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	var reqDTO dto.AdminScoreRequest
+	if !c.readBody(w, r, &reqDTO) {
+		return
+	}
+	if reqDTO.Reason == "" || reqDTO.Points == 0 {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": "Points and reason are required",
+		})
+		return
+	}
+	reason := scoreboard.Reason{
+		Code:    scoreboard.ReasonCodeAdminAdjustment,
+		Message: reqDTO.Reason,
+	}
+	var description string
+	if reqDTO.PlayerID != "" {
+		p, err := c.playerService.FindByID(reqDTO.PlayerID)
+		if err != nil {
+			response.NotFound(w, map[string]interface{}{
+				"reason": err.Error(),
+			})
+			return
+		}
+		if err = c.scoreService.AddPoint(p.ID, reqDTO.Points, reason); err != nil {
+			response.BadRequest(w, map[string]interface{}{
+				"reason": err.Error(),
+			})
+			return
+		}
+		description = fmt.Sprintf("%s received %d points for %s", p.Name, reqDTO.Points, reqDTO.Reason)
+	} else {
+		team := strings.ToLower(reqDTO.Team)
+		if err := c.scoreService.AdjustTeam(team, reqDTO.Points, reason); err != nil {
+			response.BadRequest(w, map[string]interface{}{
+				"reason": err.Error(),
+			})
+			return
+		}
+		description = fmt.Sprintf("%s team received %d points for %s", team, reqDTO.Points, reqDTO.Reason)
+	}
+	c.publishAdminAction("adjust_score", description)
+	response.Empty200(w)
+}
+
+// Handles POST /admin/combats/:id/end request
+func (c Controller) AdminEndCombat(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	var reqDTO dto.AdminEndCombatRequest
+	if !c.readBody(w, r, &reqDTO) {
+		return
+	}
+	ended, err := c.engineService.EndCombat(ps.ByName("id"), reqDTO.State)
+	if err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	c.publishAdminAction("end_combat", fmt.Sprintf("%s combat was ended as %s", ended.ID, ended.CombatState))
+	response.Empty200(w)
+}
+
+// Handles POST /admin/game/phase request
+func (c Controller) AdminSetPhase(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	var reqDTO dto.AdminPhaseRequest
+	if !c.readBody(w, r, &reqDTO) {
+		return
+	}
+	if err := c.gameService.SetPhase(reqDTO.Phase); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	c.publishAdminAction("set_phase", fmt.Sprintf("Game was moved to %s", reqDTO.Phase))
+	response.Empty200(w)
+}
+
+// Handles POST /admin/game/finish request
+func (c Controller) AdminFinishGame(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var response *ResponseCreator
+	defer c.cleanUp(w)
+	if err := c.gameService.SetPhase(game.PhaseFinished); err != nil {
+		response.BadRequest(w, map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+	c.publishAdminAction("finish_game", "Game was finished")
+	response.Empty200(w)
+}
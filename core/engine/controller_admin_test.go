@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riltech/centurion/core/auth"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/game"
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminEndpoints(t *testing.T) {
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	playerService := player.NewService(player.NewRepository())
+	scoreService := scoreboard.NewService(scoreboard.NewRepository(), playerService, scoreboard.DefaultRules())
+	assert.Nil(t, playerService.AddPlayer(player.Model{
+		ID:   "xxx",
+		Name: "John",
+		Team: player.TeamTypeAttacker,
+	}))
+	challengeService := challenge.NewService(challenge.NewRepository())
+	assert.Nil(t, challengeService.AddDefaultModules())
+	router := NewController(
+		eventBus,
+		nil,
+		playerService,
+		challengeService,
+		combat.NewService(combat.NewRepository(), combat.Timeouts{}),
+		scoreService,
+		game.NewService(eventBus, game.NewRepository(), 0, nil),
+		presence.NewService(presence.NewRepository()),
+		auth.NewService([]byte("secret")),
+		"secret",
+	).GetRouter()
+	call := func(method string, path string, token string, body interface{}) dto.CenturionResponse {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(b))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp dto.CenturionResponse
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.Equal(t, 401, call("GET", "/admin/players", "", nil).Code)
+	assert.Equal(t, 401, call("GET", "/admin/players", "wrong", nil).Code)
+	assert.Equal(t, 200, call("GET", "/admin/players", "secret", nil).Code)
+
+	assert.Equal(t, 400, call("POST", "/admin/scores", "secret", dto.AdminScoreRequest{
+		Team:   player.TeamTypeAttacker,
+		Points: 3,
+	}).Code)
+	assert.Equal(t, 200, call("POST", "/admin/scores", "secret", dto.AdminScoreRequest{
+		Team:   player.TeamTypeAttacker,
+		Points: 3,
+		Reason: "Great teamwork",
+	}).Code)
+	assert.Equal(t, 200, call("POST", "/admin/scores", "secret", dto.AdminScoreRequest{
+		PlayerID: "xxx",
+		Points:   -1,
+		Reason:   "Spamming",
+	}).Code)
+	attackers, _ := scoreService.GetBoards()
+	assert.Equal(t, 2, attackers.OverallScore)
+	p, _ := playerService.FindByID("xxx")
+	assert.Equal(t, -1, p.Score)
+
+	// Every change is visible in the score ledger
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/scores/teams/attacker", nil))
+	var teamScore dto.TeamScoreResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &teamScore))
+	assert.Equal(t, 2, teamScore.Total)
+	assert.Len(t, teamScore.Entries, 2)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/scores/players/xxx", nil))
+	var playerScore dto.PlayerScoreResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &playerScore))
+	assert.Equal(t, -1, playerScore.Total)
+	assert.Equal(t, "Spamming", playerScore.Entries[0].Message)
+	assert.Equal(t, scoreboard.ReasonCodeAdminAdjustment, playerScore.Entries[0].Code)
+	assert.Equal(t, 404, call("GET", "/scores/players/yyy", "", nil).Code)
+	assert.Equal(t, 404, call("GET", "/scores/teams/unknown", "", nil).Code)
+	assert.Equal(t, 404, call("POST", "/admin/players/yyy/kick", "secret", nil).Code)
+
+	// Challenges can be pulled and published again until they are retired
+	target := challengeService.GetChallenges()[0]
+	statusPath := "/admin/challenges/" + target.ID + "/status"
+	assert.Equal(t, 400, call("POST", statusPath, "secret", dto.AdminChallengeStatusRequest{Status: challenge.ChallengeStatusPendingVerification}).Code)
+	assert.Equal(t, 200, call("POST", statusPath, "secret", dto.AdminChallengeStatusRequest{Status: challenge.ChallengeStatusDisabled}).Code)
+	_, err := challengeService.FindByID(target.ID)
+	assert.Nil(t, err)
+	assert.NotContains(t, challengeService.GetChallenges(), target)
+	assert.Equal(t, 200, call("POST", statusPath, "secret", dto.AdminChallengeStatusRequest{Status: challenge.ChallengeStatusPublished}).Code)
+	assert.Equal(t, 200, call("POST", statusPath, "secret", dto.AdminChallengeStatusRequest{Status: challenge.ChallengeStatusRetired}).Code)
+	assert.Equal(t, 400, call("POST", statusPath, "secret", dto.AdminChallengeStatusRequest{Status: challenge.ChallengeStatusPublished}).Code)
+	assert.Equal(t, 404, call("POST", "/admin/challenges/unknown/status", "secret", dto.AdminChallengeStatusRequest{Status: challenge.ChallengeStatusRetired}).Code)
+}
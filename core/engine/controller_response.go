@@ -31,6 +31,36 @@ func (rc *ResponseCreator) BadRequest(w http.ResponseWriter, meta map[string]int
 	w.Write(b)
 }
 
+// Generic unauthorized
+func (rc *ResponseCreator) Unauthorized(w http.ResponseWriter) {
+	rc.jsonResponse(w)
+	b, err := json.Marshal(dto.CenturionResponse{
+		Message: "Unauthorized",
+		Code:    401,
+		Meta:    nil,
+	})
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	w.Write(b)
+}
+
+// Generic not found
+func (rc *ResponseCreator) NotFound(w http.ResponseWriter, meta map[string]interface{}) {
+	rc.jsonResponse(w)
+	b, err := json.Marshal(dto.CenturionResponse{
+		Message: "Not found",
+		Code:    404,
+		Meta:    meta,
+	})
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	w.Write(b)
+}
+
 // Generic 500
 func (rc *ResponseCreator) InternalServerError(w http.ResponseWriter) {
 	rc.jsonResponse(w)
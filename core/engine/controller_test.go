@@ -0,0 +1,904 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/riltech/centurion/core/auth"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/game"
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/sandbox"
+	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAuthentication(t *testing.T) {
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	authService := auth.NewService([]byte("secret"))
+	playerService := player.NewService(player.NewRepository())
+	assert.Nil(t, playerService.AddPlayer(player.Model{
+		ID:   "xxx",
+		Name: "John",
+		Team: player.TeamTypeDefender,
+	}))
+	challengeService := challenge.NewService(challenge.NewRepository())
+	combatService := combat.NewService(combat.NewRepository(), combat.Timeouts{})
+	scoreService := scoreboard.NewService(scoreboard.NewRepository(), playerService, scoreboard.DefaultRules())
+	gameService := game.NewService(eventBus, game.NewRepository(), 0, nil)
+	presenceService := presence.NewService(presence.NewRepository())
+	server := httptest.NewServer(NewController(
+		eventBus,
+		NewService(
+			eventBus,
+			playerService,
+			challengeService,
+			combatService,
+			scoreService,
+			gameService,
+			presenceService,
+			sandbox.NewService(sandbox.Limits{Timeout: time.Second, MemoryPages: 256}, 1),
+			Settings{},
+		),
+		playerService,
+		challengeService,
+		combatService,
+		scoreService,
+		gameService,
+		presenceService,
+		authService,
+		"",
+	).GetRouter())
+	defer server.Close()
+
+	for _, join := range []dto.JoinEvent{
+		{ID: "xxx"},
+		{ID: "xxx", Token: "xxx.forged"},
+		{ID: "yyy", Token: authService.Issue("xxx")},
+	} {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/team/join", nil)
+		assert.Nil(t, err)
+		assert.Nil(t, conn.WriteJSON(join))
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, dto.CloseCodeInvalidCredentials))
+		conn.Close()
+	}
+
+	install := func(token string, difficulty string) dto.CenturionResponse {
+		b, _ := json.Marshal(dto.InstallChallengeRequest{
+			DefenderID: "xxx",
+			Token:      token,
+			Name:       "Reverse",
+			Difficulty: difficulty,
+		})
+		rec := httptest.NewRecorder()
+		server.Config.Handler.ServeHTTP(rec, httptest.NewRequest("POST", "/challenges", bytes.NewBuffer(b)))
+		var resp dto.CenturionResponse
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+	assert.Equal(t, 401, install("", "").Code)
+	assert.Equal(t, 401, install(authService.Issue("yyy"), "").Code)
+	assert.Equal(t, 400, install(authService.Issue("xxx"), "impossible").Code)
+	assert.Equal(t, 200, install(authService.Issue("xxx"), "").Code)
+
+	// The challenge waits for the verification as the defender is offline
+	pending := challengeService.GetPendingChallenges("xxx")
+	assert.Len(t, pending, 1)
+	assert.Equal(t, challenge.DifficultyMedium, pending[0].Difficulty)
+	rec := httptest.NewRecorder()
+	server.Config.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/challenges", nil))
+	var challenges dto.FetchChallengesResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &challenges))
+	assert.Empty(t, challenges.Challenges)
+	rec = httptest.NewRecorder()
+	server.Config.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/uptime", nil))
+	var uptime dto.UptimeResponse
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &uptime))
+	assert.Empty(t, uptime.Defenders)
+}
+
+// Describes a running engine over a test server
+type testServer struct {
+	server           *httptest.Server
+	eventBus         bus.IBus
+	authService      auth.IService
+	playerService    player.IService
+	challengeService challenge.IService
+	combatService    combat.IService
+	gameService      game.IService
+	engineService    IService
+}
+
+// Starts an engine with a registered defender (xxx)
+// and attacker (yyy) over a test server
+func newTestServer(t *testing.T, settings Settings) testServer {
+	eventBus := bus.NewBus()
+	authService := auth.NewService([]byte("secret"))
+	playerService := player.NewService(player.NewRepository())
+	assert.Nil(t, playerService.AddPlayer(player.Model{
+		ID:   "xxx",
+		Name: "John",
+		Team: player.TeamTypeDefender,
+	}))
+	assert.Nil(t, playerService.AddPlayer(player.Model{
+		ID:   "yyy",
+		Name: "Jane",
+		Team: player.TeamTypeAttacker,
+	}))
+	challengeService := challenge.NewService(challenge.NewRepository())
+	assert.Nil(t, challengeService.AddDefaultModules())
+	combatService := combat.NewService(combat.NewRepository(), combat.Timeouts{})
+	scoreService := scoreboard.NewService(scoreboard.NewRepository(), playerService, scoreboard.DefaultRules())
+	gameService := game.NewService(eventBus, game.NewRepository(), 0, nil)
+	presenceService := presence.NewService(presence.NewRepository())
+	engineService := NewService(
+		eventBus,
+		playerService,
+		challengeService,
+		combatService,
+		scoreService,
+		gameService,
+		presenceService,
+		sandbox.NewService(sandbox.Limits{Timeout: time.Second, MemoryPages: 256}, 1),
+		settings,
+	)
+	server := httptest.NewServer(NewController(
+		eventBus,
+		engineService,
+		playerService,
+		challengeService,
+		combatService,
+		scoreService,
+		gameService,
+		presenceService,
+		authService,
+		"",
+	).GetRouter())
+	return testServer{server, eventBus, authService, playerService, challengeService, combatService, gameService, engineService}
+}
+
+func (ts testServer) Close() {
+	ts.server.Close()
+	ts.eventBus.Stop()
+}
+
+// Joins with a given player of the test server
+func (ts testServer) join(t *testing.T, ID string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.server.URL, "http")+"/team/join", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, conn.WriteJSON(dto.JoinEvent{ID: ID, Token: ts.authService.Issue(ID)}))
+	return conn
+}
+
+func readEventType(conn *websocket.Conn) (string, error) {
+	var event dto.SocketEvent
+	err := conn.ReadJSON(&event)
+	return event.Type, err
+}
+
+func TestSessionPolicy(t *testing.T) {
+	for _, policy := range []string{SessionPolicyReplace, SessionPolicyReject} {
+		ts := newTestServer(t, Settings{SessionPolicy: policy})
+
+		first := ts.join(t, "xxx")
+		eventType, err := readEventType(first)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.SocketEventTypeGamePhase, eventType)
+		second := ts.join(t, "xxx")
+		if policy == SessionPolicyReplace {
+			eventType, err = readEventType(first)
+			assert.Nil(t, err)
+			assert.Equal(t, dto.SocketEventTypeSessionReplaced, eventType)
+			_, err = readEventType(first)
+			assert.True(t, websocket.IsCloseError(err, dto.CloseCodeSessionReplaced))
+			eventType, err = readEventType(second)
+			assert.Nil(t, err)
+			assert.Equal(t, dto.SocketEventTypeGamePhase, eventType)
+		} else {
+			_, err = readEventType(second)
+			assert.True(t, websocket.IsCloseError(err, dto.CloseCodeSessionAlreadyActive))
+		}
+		p, err := ts.playerService.FindByID("xxx")
+		assert.Nil(t, err)
+		assert.True(t, p.Online)
+
+		first.Close()
+		second.Close()
+		ts.Close()
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	ts := newTestServer(t, Settings{
+		SessionPolicy:     SessionPolicyReplace,
+		HeartbeatInterval: 20 * time.Millisecond,
+		HeartbeatTimeout:  100 * time.Millisecond,
+	})
+	defer ts.Close()
+	timeoutCh := ts.eventBus.Listen(bus.EventTypeCombatTimeout)
+	assert.Nil(t, ts.combatService.AddCombat(combat.Model{
+		ID:          "combat",
+		AttackerID:  "yyy",
+		DefenderID:  "xxx",
+		CombatState: combat.CombatStateDefenseRequested,
+	}))
+	isOnline := func() bool {
+		p, err := ts.playerService.FindByID("xxx")
+		assert.Nil(t, err)
+		return p.Online
+	}
+
+	// A reading client answers the pings
+	conn := ts.join(t, "xxx")
+	defer conn.Close()
+	answering := int32(1)
+	conn.SetPingHandler(func(data string) error {
+		if atomic.LoadInt32(&answering) == 0 {
+			return nil
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, err := readEventType(conn); err != nil {
+				return
+			}
+		}
+	}()
+	<-time.After(300 * time.Millisecond)
+	assert.True(t, isOnline())
+	p, _ := ts.playerService.FindByID("xxx")
+	assert.WithinDuration(t, time.Now(), p.LastSeenAt, 100*time.Millisecond)
+
+	// A client which stops answering is dropped and its combats fail
+	atomic.StoreInt32(&answering, 0)
+	select {
+	case <-timeoutCh:
+	case <-time.After(time.Second):
+		t.Fatal("Combat of the dropped defender did not fail")
+	}
+	assert.False(t, isOnline())
+	c, err := ts.combatService.FindByID("combat")
+	assert.Nil(t, err)
+	assert.Equal(t, combat.CombatStateDefenseFailed, c.CombatState)
+}
+
+func TestDefaultModuleCombat(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	target := ts.challengeService.GetChallenges()[0]
+	conn := ts.join(t, "yyy")
+	defer conn.Close()
+	var phase dto.GamePhaseEvent
+	assert.Nil(t, conn.ReadJSON(&phase))
+
+	attack := func() dto.AttackChallengeEvent {
+		assert.Nil(t, conn.WriteJSON(dto.AttackEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+			TargetID:    target.ID,
+		}))
+		var challenged dto.AttackChallengeEvent
+		assert.Nil(t, conn.ReadJSON(&challenged))
+		assert.Equal(t, dto.SocketEventTypeAttackChallenge, challenged.Type)
+		return challenged
+	}
+	solve := func(hints []interface{}, solution string) dto.AttackResultEvent {
+		assert.Nil(t, conn.WriteJSON(dto.AttackSolutionEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttackSolution},
+			TargetID:    target.ID,
+			Hints:       hints,
+			Solutions:   []interface{}{solution},
+		}))
+		var result dto.AttackResultEvent
+		assert.Nil(t, conn.ReadJSON(&result))
+		assert.Equal(t, dto.SocketEventTypeAttackResult, result.Type)
+		return result
+	}
+	reverse := func(hint interface{}) string {
+		runes := []rune(hint.(string))
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	}
+
+	challenged := attack()
+	assert.False(t, solve(challenged.Hints, "wrong").Success)
+	challenged = attack()
+	assert.True(t, solve(challenged.Hints, reverse(challenged.Hints[0])).Success)
+
+	// Both attempts went through the combat lifecycle against the system
+	assert.True(t, ts.combatService.GetCompletionMatrix().IsCompletedBy(target.ID, "yyy"))
+	assert.Equal(t, 50, ts.combatService.GetAttackerSuccessPercent())
+	p, err := ts.playerService.FindByID("yyy")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, p.Score)
+}
+
+func TestAttackCooldown(t *testing.T) {
+	ts := newTestServer(t, Settings{
+		SessionPolicy: SessionPolicyReplace,
+		RateLimits:    RateLimits{FailureCooldown: time.Minute},
+	})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	target := ts.challengeService.GetChallenges()[0]
+	conn := ts.join(t, "yyy")
+	defer conn.Close()
+	var phase dto.GamePhaseEvent
+	assert.Nil(t, conn.ReadJSON(&phase))
+	attack := func() {
+		assert.Nil(t, conn.WriteJSON(dto.AttackEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+			TargetID:    target.ID,
+		}))
+	}
+
+	attack()
+	var challenged dto.AttackChallengeEvent
+	assert.Nil(t, conn.ReadJSON(&challenged))
+	assert.Equal(t, dto.SocketEventTypeAttackChallenge, challenged.Type)
+	assert.Nil(t, conn.WriteJSON(dto.AttackSolutionEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttackSolution},
+		TargetID:    target.ID,
+		Hints:       challenged.Hints,
+		Solutions:   []interface{}{"wrong"},
+	}))
+	var result dto.AttackResultEvent
+	assert.Nil(t, conn.ReadJSON(&result))
+	assert.False(t, result.Success)
+
+	// The rejected solution starts a cooldown on the challenge
+	attack()
+	var rejected dto.ErrorEvent
+	assert.Nil(t, conn.ReadJSON(&rejected))
+	assert.Equal(t, dto.SocketEventTypeError, rejected.Type)
+	assert.Equal(t, dto.ErrorCodeCooldown, rejected.Code)
+	assert.Equal(t, target.ID, rejected.TargetID)
+	assert.InDelta(t, time.Minute.Milliseconds(), rejected.RetryAfter, float64(time.Second.Milliseconds()))
+}
+
+func TestSupersededCombats(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	target := ts.challengeService.GetChallenges()[0]
+	conn := ts.join(t, "yyy")
+	defer conn.Close()
+	var phase dto.GamePhaseEvent
+	assert.Nil(t, conn.ReadJSON(&phase))
+	attack := func(targetID string) {
+		assert.Nil(t, conn.WriteJSON(dto.AttackEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+			TargetID:    targetID,
+		}))
+	}
+	solve := func(challenged dto.AttackChallengeEvent) {
+		runes := []rune(challenged.Hints[0].(string))
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		assert.Nil(t, conn.WriteJSON(dto.AttackSolutionEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttackSolution},
+			TargetID:    target.ID,
+			CombatID:    challenged.CombatID,
+			Hints:       challenged.Hints,
+			Solutions:   []interface{}{string(runes)},
+		}))
+	}
+
+	// A new attack supersedes the previous one
+	var first, second dto.AttackChallengeEvent
+	attack(target.ID)
+	assert.Nil(t, conn.ReadJSON(&first))
+	attack(target.ID)
+	assert.Nil(t, conn.ReadJSON(&second))
+	assert.NotEqual(t, first.CombatID, second.CombatID)
+	solve(first)
+	var rejected dto.ErrorEvent
+	assert.Nil(t, conn.ReadJSON(&rejected))
+	assert.Equal(t, "Combat is already over, state is: "+combat.CombatStateAttackFailed, rejected.Message)
+	solve(second)
+	var result dto.AttackResultEvent
+	assert.Nil(t, conn.ReadJSON(&result))
+	assert.Equal(t, second.CombatID, result.CombatID)
+	assert.True(t, result.Success)
+
+	// Defenders are told to drop the superseded combats
+	assert.Nil(t, ts.challengeService.AddChallenge(challenge.Model{
+		ID:        "own",
+		Name:      "Reverse",
+		CreatorID: "xxx",
+		Type:      challenge.ChallengeTypePlayerCreated,
+		Status:    challenge.ChallengeStatusPublished,
+	}))
+	defender := ts.join(t, "xxx")
+	defer defender.Close()
+	assert.Nil(t, defender.ReadJSON(&phase))
+	var requested, superseding dto.DefendActionRequestEvent
+	attack("own")
+	assert.Nil(t, defender.ReadJSON(&requested))
+	attack("own")
+	var dropped dto.AttackerFailedToAttackEvent
+	assert.Nil(t, defender.ReadJSON(&dropped))
+	assert.Equal(t, dto.SocketEventTypeAttackerFailedToAttack, dropped.Type)
+	assert.Equal(t, requested.CombatID, dropped.CombatID)
+	assert.Nil(t, defender.ReadJSON(&superseding))
+	assert.Equal(t, dto.SocketEventTypeDefendActionRequest, superseding.Type)
+	latest, err := ts.combatService.FindByAttackerAndChallenge("yyy", "own")
+	assert.Nil(t, err)
+	assert.Equal(t, superseding.CombatID, latest.ID)
+}
+
+func TestSolutionsOutsideOfRunningPhase(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	assert.Nil(t, ts.challengeService.AddChallenge(challenge.Model{
+		ID:        "own",
+		Name:      "Reverse",
+		CreatorID: "xxx",
+		Type:      challenge.ChallengeTypePlayerCreated,
+		Status:    challenge.ChallengeStatusPublished,
+	}))
+	var phase dto.GamePhaseEvent
+	defender := ts.join(t, "xxx")
+	defer defender.Close()
+	assert.Nil(t, defender.ReadJSON(&phase))
+	attacker := ts.join(t, "yyy")
+	defer attacker.Close()
+	assert.Nil(t, attacker.ReadJSON(&phase))
+
+	assert.Nil(t, attacker.WriteJSON(dto.AttackEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+		TargetID:    "own",
+	}))
+	var requested dto.DefendActionRequestEvent
+	assert.Nil(t, defender.ReadJSON(&requested))
+	assert.Nil(t, defender.WriteJSON(dto.DefendActionEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeDefendAction},
+		Hints:       []interface{}{"abc"},
+		CombatID:    requested.CombatID,
+	}))
+	var challenged dto.AttackChallengeEvent
+	assert.Nil(t, attacker.ReadJSON(&challenged))
+	solution := dto.AttackSolutionEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttackSolution},
+		TargetID:    "own",
+		CombatID:    challenged.CombatID,
+		Hints:       challenged.Hints,
+		Solutions:   []interface{}{"cba"},
+	}
+
+	assert.Nil(t, attacker.WriteJSON(solution))
+	var evaluation dto.SolutionEvaluationRequestEvent
+	assert.Nil(t, defender.ReadJSON(&evaluation))
+
+	// Evaluations arriving after a pause score no points
+	assert.Nil(t, ts.gameService.SetPhase(game.PhasePaused))
+	assert.Nil(t, defender.WriteJSON(dto.SolutionEvaluationEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeSolutionEvaluation},
+		TargetID:    "own",
+		CombatID:    evaluation.CombatID,
+		Success:     true,
+	}))
+	var result dto.AttackResultEvent
+	assert.Nil(t, attacker.ReadJSON(&result))
+	assert.False(t, result.Success)
+	assert.Equal(t, ts.gameService.CanAttack().Error(), result.Message)
+	assert.False(t, ts.combatService.GetCompletionMatrix().IsCompletedBy("own", "yyy"))
+	for _, ID := range []string{"xxx", "yyy"} {
+		p, _ := ts.playerService.FindByID(ID)
+		assert.Equal(t, 0, p.Score)
+	}
+
+	// Solutions are not accepted while the game is paused
+	assert.Nil(t, attacker.WriteJSON(solution))
+	var rejected dto.ErrorEvent
+	assert.Nil(t, attacker.ReadJSON(&rejected))
+	assert.Equal(t, ts.gameService.CanAttack().Error(), rejected.Message)
+}
+
+func TestDefenderActions(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	assert.Nil(t, ts.playerService.AddPlayer(player.Model{
+		ID:   "zzz",
+		Name: "Joe",
+		Team: player.TeamTypeDefender,
+	}))
+	assert.Nil(t, ts.challengeService.AddChallenge(challenge.Model{
+		ID:        "own",
+		Name:      "Reverse",
+		CreatorID: "xxx",
+		Type:      challenge.ChallengeTypePlayerCreated,
+		Status:    challenge.ChallengeStatusPublished,
+	}))
+	var phase dto.GamePhaseEvent
+	defender := ts.join(t, "xxx")
+	defer defender.Close()
+	assert.Nil(t, defender.ReadJSON(&phase))
+	other := ts.join(t, "zzz")
+	defer other.Close()
+	assert.Nil(t, other.ReadJSON(&phase))
+	attacker := ts.join(t, "yyy")
+	defer attacker.Close()
+	assert.Nil(t, attacker.ReadJSON(&phase))
+
+	assert.Nil(t, attacker.WriteJSON(dto.AttackEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+		TargetID:    "own",
+	}))
+	var requested dto.DefendActionRequestEvent
+	assert.Nil(t, defender.ReadJSON(&requested))
+	defend := dto.DefendActionEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeDefendAction},
+		Hints:       []interface{}{"abc"},
+		CombatID:    requested.CombatID,
+	}
+	var rejected dto.ErrorEvent
+
+	// Only the defender of the combat can act in it
+	assert.Nil(t, other.WriteJSON(defend))
+	assert.Nil(t, other.ReadJSON(&rejected))
+	assert.Equal(t, "Invalid combat ID", rejected.Message)
+
+	// Evaluations are only accepted once a solution was sent
+	assert.Nil(t, defender.WriteJSON(dto.SolutionEvaluationEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeSolutionEvaluation},
+		TargetID:    "own",
+		CombatID:    requested.CombatID,
+		Success:     true,
+	}))
+	assert.Nil(t, defender.ReadJSON(&rejected))
+	assert.Equal(t, "Evaluation is not expected for the combat", rejected.Message)
+
+	assert.Nil(t, defender.WriteJSON(defend))
+	var challenged dto.AttackChallengeEvent
+	assert.Nil(t, attacker.ReadJSON(&challenged))
+	assert.Equal(t, requested.CombatID, challenged.CombatID)
+
+	// Hints cannot be changed once the attacker was challenged
+	assert.Nil(t, defender.WriteJSON(defend))
+	assert.Nil(t, defender.ReadJSON(&rejected))
+	assert.Equal(t, "Hints are not expected for the combat", rejected.Message)
+	p, _ := ts.playerService.FindByID("yyy")
+	assert.Equal(t, 0, p.Score)
+}
+
+func TestChallengeVerification(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	conn := ts.join(t, "xxx")
+	defer conn.Close()
+	var phase dto.GamePhaseEvent
+	assert.Nil(t, conn.ReadJSON(&phase))
+
+	install := func(name string) string {
+		b, _ := json.Marshal(dto.InstallChallengeRequest{
+			Token: ts.authService.Issue("xxx"),
+			Name:  name,
+			Example: dto.ChallengeExampleDTO{
+				Hints:     []interface{}{"123"},
+				Solutions: []interface{}{"321"},
+			},
+		})
+		resp, err := http.Post(ts.server.URL+"/challenges", "application/json", bytes.NewBuffer(b))
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		var installed dto.InstallChallengeResponse
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(&installed))
+		assert.Equal(t, challenge.ChallengeStatusPendingVerification, installed.Status)
+		return installed.ID
+	}
+	// Plays the defender side of the verification
+	// validate decides if a given solution is accepted
+	defend := func(ID string, validate func(solution interface{}) bool) dto.ChallengeVerificationEvent {
+		var request dto.DefendActionRequestEvent
+		assert.Nil(t, conn.ReadJSON(&request))
+		assert.Equal(t, dto.SocketEventTypeDefendActionRequest, request.Type)
+		assert.Equal(t, ID, request.TargetID)
+		assert.Nil(t, conn.WriteJSON(dto.DefendActionEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeDefendAction},
+			Hints:       []interface{}{"456"},
+			CombatID:    request.CombatID,
+		}))
+		for {
+			_, b, err := conn.ReadMessage()
+			assert.Nil(t, err)
+			var event dto.SocketEvent
+			assert.Nil(t, json.Unmarshal(b, &event))
+			if event.Type == dto.SocketEventTypeChallengeVerification {
+				var result dto.ChallengeVerificationEvent
+				assert.Nil(t, json.Unmarshal(b, &result))
+				return result
+			}
+			var evaluation dto.SolutionEvaluationRequestEvent
+			assert.Nil(t, json.Unmarshal(b, &evaluation))
+			assert.Equal(t, dto.SocketEventTypeSolutionEvaluationRequest, evaluation.Type)
+			assert.Equal(t, []interface{}{"123"}, evaluation.Hints)
+			assert.Nil(t, conn.WriteJSON(dto.SolutionEvaluationEvent{
+				SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeSolutionEvaluation},
+				TargetID:    ID,
+				CombatID:    evaluation.CombatID,
+				Success:     validate(evaluation.Solutions[0]),
+			}))
+		}
+	}
+
+	// A defender accepting anything fails and the challenge is removed
+	ID := install("Lenient")
+	result := defend(ID, func(interface{}) bool { return true })
+	assert.False(t, result.Success)
+	_, err := ts.challengeService.FindByID(ID)
+	assert.NotNil(t, err)
+
+	// A defender checking the solutions passes and the challenge is published
+	ID = install("Strict")
+	result = defend(ID, func(solution interface{}) bool { return solution == "321" })
+	assert.True(t, result.Success)
+	published, err := ts.challengeService.FindByID(ID)
+	assert.Nil(t, err)
+	assert.True(t, published.IsPublished())
+	p, _ := ts.playerService.FindByID("xxx")
+	assert.Equal(t, 1, p.Score)
+
+	// Verification probes do not count as attacks
+	resp, err := http.Get(ts.server.URL + "/completions")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	var completions dto.CompletionResponse
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&completions))
+	assert.Equal(t, 1, completions.Attackers)
+	assert.Len(t, completions.Challenges, len(ts.challengeService.GetChallenges()))
+	for _, c := range completions.Challenges {
+		assert.Empty(t, c.Solvers)
+	}
+	assert.Equal(t, 0, ts.combatService.GetDefenseFailPercent())
+
+	// Published challenges are audited with their example
+	assert.Nil(t, ts.engineService.AuditChallenge(ID))
+	var audit dto.SolutionEvaluationRequestEvent
+	assert.Nil(t, conn.ReadJSON(&audit))
+	assert.Equal(t, dto.SocketEventTypeSolutionEvaluationRequest, audit.Type)
+	assert.Equal(t, []interface{}{"321"}, audit.Solutions)
+	assert.Nil(t, conn.WriteJSON(dto.SolutionEvaluationEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeSolutionEvaluation},
+		TargetID:    ID,
+		CombatID:    audit.CombatID,
+		Success:     false,
+	}))
+	assert.Eventually(t, func() bool {
+		return ts.combatService.GetAuditResults()["xxx"].Failed == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		p, _ := ts.playerService.FindByID("xxx")
+		return p.Score == -1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestChallengeLifecycle(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	assert.Nil(t, ts.challengeService.AddChallenge(challenge.Model{
+		ID:         "own",
+		Name:       "Reverse",
+		CreatorID:  "xxx",
+		Type:       challenge.ChallengeTypePlayerCreated,
+		Difficulty: challenge.DifficultyEasy,
+		Status:     challenge.ChallengeStatusPublished,
+		Example: challenge.Example{
+			Hints:     []interface{}{"123"},
+			Solutions: []interface{}{"321"},
+		},
+	}))
+	update := func(token string, reqDTO dto.UpdateChallengeRequest) dto.UpdateChallengeResponse {
+		reqDTO.Token = token
+		b, _ := json.Marshal(reqDTO)
+		req, err := http.NewRequest("PUT", ts.server.URL+"/challenges/own", bytes.NewBuffer(b))
+		assert.Nil(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		var updated dto.UpdateChallengeResponse
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(&updated))
+		return updated
+	}
+
+	// Only the creator can update the challenge
+	assert.Equal(t, 401, update(ts.authService.Issue("yyy"), dto.UpdateChallengeRequest{Description: "Hijacked"}).Code)
+	assert.Equal(t, 400, update(ts.authService.Issue("xxx"), dto.UpdateChallengeRequest{Difficulty: "extreme"}).Code)
+	updated := update(ts.authService.Issue("xxx"), dto.UpdateChallengeRequest{
+		Description: "Reverse the hint",
+		Difficulty:  challenge.DifficultyHard,
+	})
+	assert.Equal(t, 200, updated.Code)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, challenge.ChallengeStatusPublished, updated.Status)
+	previous, err := ts.challengeService.FindVersion("own", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, challenge.DifficultyEasy, previous.Difficulty)
+	current, _ := ts.challengeService.FindByID("own")
+	assert.Equal(t, "Reverse the hint", current.Description)
+	assert.Equal(t, []interface{}{"321"}, current.Example.Solutions)
+
+	// A new example has to pass the verification again
+	updated = update(ts.authService.Issue("xxx"), dto.UpdateChallengeRequest{
+		Example: dto.ChallengeExampleDTO{
+			Hints:     []interface{}{"abc"},
+			Solutions: []interface{}{"cba"},
+		},
+	})
+	assert.Equal(t, 3, updated.Version)
+	assert.Equal(t, challenge.ChallengeStatusPendingVerification, updated.Status)
+	assert.Equal(t, 400, update(ts.authService.Issue("xxx"), dto.UpdateChallengeRequest{Description: "Again"}).Code)
+	// The current version stays published until the new one is verified
+	current, _ = ts.challengeService.FindByID("own")
+	assert.Equal(t, 2, current.Version)
+	assert.True(t, current.IsPublished())
+	assert.Equal(t, []interface{}{"321"}, current.Example.Solutions)
+	defender := ts.join(t, "xxx")
+	defer defender.Close()
+	var phase dto.GamePhaseEvent
+	assert.Nil(t, defender.ReadJSON(&phase))
+	var request dto.DefendActionRequestEvent
+	assert.Nil(t, defender.ReadJSON(&request))
+	assert.Nil(t, defender.WriteJSON(dto.DefendActionEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeDefendAction},
+		Hints:       []interface{}{"xyz"},
+		CombatID:    request.CombatID,
+	}))
+	for _, expected := range []bool{true, false} {
+		var evaluation dto.SolutionEvaluationRequestEvent
+		assert.Nil(t, defender.ReadJSON(&evaluation))
+		assert.Equal(t, expected, evaluation.Solutions[0] == "cba")
+		assert.Nil(t, defender.WriteJSON(dto.SolutionEvaluationEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeSolutionEvaluation},
+			TargetID:    "own",
+			CombatID:    evaluation.CombatID,
+			Success:     expected,
+		}))
+	}
+	var verified dto.ChallengeVerificationEvent
+	assert.Nil(t, defender.ReadJSON(&verified))
+	assert.True(t, verified.Success)
+	current, _ = ts.challengeService.FindByID("own")
+	assert.Equal(t, 3, current.Version)
+	assert.True(t, current.IsPublished())
+	assert.Equal(t, []interface{}{"cba"}, current.Example.Solutions)
+
+	// Attacks on pulled challenges fail cleanly
+	target := ts.challengeService.GetChallenges()[0]
+	conn := ts.join(t, "yyy")
+	defer conn.Close()
+	assert.Nil(t, conn.ReadJSON(&phase))
+	assert.Nil(t, conn.WriteJSON(dto.AttackEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+		TargetID:    target.ID,
+	}))
+	var challenged dto.AttackChallengeEvent
+	assert.Nil(t, conn.ReadJSON(&challenged))
+	_, err = ts.challengeService.SetStatus(target.ID, challenge.ChallengeStatusRetired)
+	assert.Nil(t, err)
+	assert.Nil(t, conn.WriteJSON(dto.AttackSolutionEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttackSolution},
+		TargetID:    target.ID,
+		Hints:       challenged.Hints,
+		Solutions:   []interface{}{"anything"},
+	}))
+	var failed dto.ErrorEvent
+	assert.Nil(t, conn.ReadJSON(&failed))
+	assert.Equal(t, "Challenge is retired", failed.Message)
+	_, err = ts.combatService.FindByAttackerAndChallenge("yyy", target.ID)
+	assert.NotNil(t, err)
+	assert.Nil(t, conn.WriteJSON(dto.AttackEvent{
+		SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+		TargetID:    target.ID,
+	}))
+	assert.Nil(t, conn.ReadJSON(&failed))
+	assert.Equal(t, "Challenge is retired", failed.Message)
+	for _, c := range ts.challengeService.GetChallenges() {
+		assert.NotEqual(t, target.ID, c.ID)
+	}
+}
+
+func TestHostedChallenge(t *testing.T) {
+	ts := newTestServer(t, Settings{SessionPolicy: SessionPolicyReplace})
+	defer ts.Close()
+	assert.Nil(t, ts.gameService.SetPhase(game.PhaseRunning))
+	module, err := os.ReadFile("../sandbox/testdata/reverse.wasm")
+	assert.Nil(t, err)
+
+	install := func(module []byte, solution string) dto.CenturionResponse {
+		b, _ := json.Marshal(dto.InstallChallengeRequest{
+			Token: ts.authService.Issue("xxx"),
+			Name:  "Hosted " + solution,
+			Example: dto.ChallengeExampleDTO{
+				Hints:     []interface{}{"abc"},
+				Solutions: []interface{}{solution},
+			},
+			Module: module,
+		})
+		resp, err := http.Post(ts.server.URL+"/challenges", "application/json", bytes.NewBuffer(b))
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		var installed dto.InstallChallengeResponse
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(&installed))
+		return installed.CenturionResponse
+	}
+
+	// Modules are validated before the challenge is installed
+	assert.Equal(t, http.StatusBadRequest, install([]byte("not a module"), "cba").Code)
+
+	// Modules are verified without the creator being online
+	assert.Equal(t, http.StatusOK, install(module, "xyz").Code)
+	assert.Equal(t, http.StatusOK, install(module, "cba").Code)
+	var target challenge.Model
+	for _, c := range ts.challengeService.GetChallenges() {
+		if c.IsHosted() {
+			target = c
+		}
+	}
+	assert.Equal(t, "Hosted cba", target.Name)
+	assert.True(t, target.IsPublished())
+
+	// Attacks are served by the module while the creator is offline
+	conn := ts.join(t, "yyy")
+	defer conn.Close()
+	var phase dto.GamePhaseEvent
+	assert.Nil(t, conn.ReadJSON(&phase))
+	solve := func(solution string) dto.AttackResultEvent {
+		assert.Nil(t, conn.WriteJSON(dto.AttackEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttack},
+			TargetID:    target.ID,
+		}))
+		var challenged dto.AttackChallengeEvent
+		assert.Nil(t, conn.ReadJSON(&challenged))
+		assert.Equal(t, dto.SocketEventTypeAttackChallenge, challenged.Type)
+		assert.Equal(t, []interface{}{"abc"}, challenged.Hints)
+		assert.Nil(t, conn.WriteJSON(dto.AttackSolutionEvent{
+			SocketEvent: dto.SocketEvent{Type: dto.SocketEventTypeAttackSolution},
+			TargetID:    target.ID,
+			Hints:       challenged.Hints,
+			Solutions:   []interface{}{solution},
+		}))
+		var result dto.AttackResultEvent
+		assert.Nil(t, conn.ReadJSON(&result))
+		assert.Equal(t, dto.SocketEventTypeAttackResult, result.Type)
+		return result
+	}
+	// Solutions the module fails to evaluate are rejected without points
+	// NOTE: The input of this one does not fit in the memory of the module
+	score := func(ID string) int {
+		p, _ := ts.playerService.FindByID(ID)
+		return p.Score
+	}
+	defenderScore, attackerScore := score("xxx"), score("yyy")
+	failed := solve(strings.Repeat("a", 70000))
+	assert.False(t, failed.Success)
+	assert.Equal(t, "Solutions could not be evaluated", failed.Message)
+	assert.Equal(t, defenderScore, score("xxx"))
+	assert.Equal(t, attackerScore, score("yyy"))
+	assert.False(t, solve("abc").Success)
+	assert.True(t, solve("cba").Success)
+	assert.True(t, ts.combatService.GetCompletionMatrix().IsCompletedBy(target.ID, "yyy"))
+	// The creator gets no defense flow points for the combats of the module
+	assert.Equal(t, defenderScore, score("xxx"))
+}
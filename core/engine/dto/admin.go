@@ -0,0 +1,47 @@
+package dto
+
+// Describes a player in the admin player list
+type AdminPlayerDTO struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Team   string `json:"team"`
+	Score  int    `json:"score"`
+	Online bool   `json:"online"`
+}
+
+// Describes the response of the admin player list
+type AdminPlayersResponse struct {
+	CenturionResponse
+	Players []*AdminPlayerDTO `json:"players"`
+}
+
+// Describes a manual score adjustment
+// NOTE: Either the player ID or the team has to be provided
+type AdminScoreRequest struct {
+	// ID of the player to adjust the score of
+	PlayerID string `json:"playerId"`
+	// Team to adjust the score of
+	Team string `json:"team"`
+	// Points to add, can be negative
+	Points int `json:"points"`
+	// Reason of the adjustment
+	Reason string `json:"reason"`
+}
+
+// Describes a request to end a combat
+type AdminEndCombatRequest struct {
+	// Final state of the combat
+	State string `json:"state"`
+}
+
+// Describes a request to move the game into a given phase
+type AdminPhaseRequest struct {
+	// Phase of the game
+	Phase string `json:"phase"`
+}
+
+// Describes a request to change the status of a challenge
+type AdminChallengeStatusRequest struct {
+	// Either "published", "disabled" or "retired"
+	Status string `json:"status"`
+}
@@ -11,7 +11,14 @@ type ChallengeResponseDTO struct {
 	ID          string              `json:"id"`
 	Name        string              `json:"name"`
 	Description string              `json:"description"`
+	Difficulty  string              `json:"difficulty"`
 	Example     ChallengeExampleDTO `json:"example"`
+	// Version of the challenge, increased by every update
+	Version int `json:"version"`
+	// Number of attackers who solved the challenge
+	Solvers int `json:"solvers"`
+	// Percentage of the attackers who solved the challenge
+	SolveRate int `json:"solveRate"`
 }
 
 // Describes an example in a challenge DTO
@@ -24,13 +31,24 @@ type ChallengeExampleDTO struct {
 type InstallChallengeRequest struct {
 	// ID of the defender
 	DefenderID string `json:"defenderId"`
+	// Secret token of the defender received at registration
+	Token string `json:"token"`
 	// Name of the challenge
 	// NOTE: This has to be unique throughut the game
 	Name string `json:"name"`
 	// Description of the challenge
 	Description string `json:"description"`
+	// Difficulty of the challenge, either "easy", "medium" or "hard"
+	// NOTE: Medium is used if it is empty
+	Difficulty string `json:"difficulty"`
 	// Example for the challenge
 	Example ChallengeExampleDTO
+	// Base64 encoded WebAssembly module serving the challenge
+	// while the defender is offline (optional)
+	Module []byte `json:"module"`
+	// Describes if the defender serves the attacks while being online
+	// even though the challenge has a module
+	LiveEvaluation bool `json:"liveEvaluation"`
 }
 
 // Success response of challenge installation endpoint
@@ -40,4 +58,37 @@ type InstallChallengeResponse struct {
 	// NOTE: you should persist this ID as you will be required
 	// to provide hints and validations based on this ID
 	ID string `json:"id"`
+	// Status of the challenge, it is "pending_verification"
+	// until you pass the verification over the websocket
+	Status string `json:"status"`
+}
+
+// Describes a request body received in update challenge endpoint
+// NOTE: Empty fields keep their current value
+type UpdateChallengeRequest struct {
+	// Secret token of the creator received at registration
+	Token string `json:"token"`
+	// Description of the challenge
+	Description string `json:"description"`
+	// Difficulty of the challenge, either "easy", "medium" or "hard"
+	Difficulty string `json:"difficulty"`
+	// Example for the challenge
+	// NOTE: A new example has to pass the verification again
+	Example ChallengeExampleDTO `json:"example"`
+	// Base64 encoded WebAssembly module serving the challenge
+	// NOTE: A new module has to pass the verification again
+	Module []byte `json:"module"`
+	// Describes if the creator serves the attacks while being online
+	LiveEvaluation *bool `json:"liveEvaluation"`
+}
+
+// Success response of challenge update endpoint
+type UpdateChallengeResponse struct {
+	CenturionResponse
+	ID string `json:"id"`
+	// Version of the challenge after the update
+	Version int `json:"version"`
+	// Status of the challenge, it is "pending_verification"
+	// if the example changed until you pass the verification again
+	Status string `json:"status"`
 }
@@ -0,0 +1,19 @@
+package dto
+
+// Describes the completion of a single challenge
+type ChallengeCompletionDTO struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// IDs of the attackers who solved the challenge
+	Solvers []string `json:"solvers"`
+	// Percentage of the attackers who solved the challenge
+	Percent int `json:"percent"`
+}
+
+// Describes the completion matrix response
+type CompletionResponse struct {
+	CenturionResponse
+	// Number of attackers in the game
+	Attackers  int                      `json:"attackers"`
+	Challenges []ChallengeCompletionDTO `json:"challenges"`
+}
@@ -10,4 +10,8 @@ type RegisterRequest struct {
 type RegisterResponse struct {
 	CenturionResponse
 	ID string `json:"id"`
+	// Secret token of the player
+	// NOTE: Keep it secret, it is required to join the game
+	// and to install challenges
+	Token string `json:"token"`
 }
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// Describes a single entry of the score ledger
+type ScoreEntryDTO struct {
+	At time.Time `json:"at"`
+	// Empty if only the team received the points
+	PlayerID string `json:"playerId,omitempty"`
+	Team     string `json:"team"`
+	// Points given, negative for penalties
+	Delta int `json:"delta"`
+	// Reason code of the entry, see docs/api.md
+	Code        string `json:"code"`
+	CombatID    string `json:"combatId,omitempty"`
+	ChallengeID string `json:"challengeId,omitempty"`
+	Message     string `json:"message"`
+}
+
+// Describes the score breakdown of a player
+type PlayerScoreResponse struct {
+	CenturionResponse
+	ID      string          `json:"id"`
+	Name    string          `json:"name"`
+	Team    string          `json:"team"`
+	Total   int             `json:"total"`
+	Entries []ScoreEntryDTO `json:"entries"`
+}
+
+// Describes the score ledger of a team
+type TeamScoreResponse struct {
+	CenturionResponse
+	Team    string          `json:"team"`
+	Total   int             `json:"total"`
+	Entries []ScoreEntryDTO `json:"entries"`
+}
@@ -13,6 +13,20 @@ const SocketEventTypeDefendActionRequest = "defend_action_request"
 const SocketEventTypeDefendAction = "defend_action"
 const SocketEventTypeSolutionEvaluationRequest = "solution_evaluation_request"
 const SocketEventTypeSolutionEvaluation = "solution_evaluation"
+const SocketEventTypeCombatTimeout = "combat_timeout"
+const SocketEventTypeGamePhase = "game_phase"
+const SocketEventTypeCountdown = "countdown"
+const SocketEventTypeSessionReplaced = "session_replaced"
+const SocketEventTypeChallengeVerification = "challenge_verification"
+
+// Close code sent when the join credentials are invalid
+const CloseCodeInvalidCredentials = 4001
+
+// Close code sent when the player already has an active session
+const CloseCodeSessionAlreadyActive = 4002
+
+// Close code sent to a session which was replaced by a new one
+const CloseCodeSessionReplaced = 4003
 
 // Describes a generic event over websockets
 type SocketEvent struct {
@@ -24,6 +38,8 @@ type JoinEvent struct {
 	SocketEvent
 	// ID which the player uses from the registration
 	ID string `json:"id"`
+	// Secret token received at registration
+	Token string `json:"token"`
 }
 
 // Happens when a new attack is launched
@@ -39,6 +55,9 @@ type AttackChallengeEvent struct {
 	SocketEvent
 	// ID of the challenge
 	TargetID string `json:"targetId"`
+	// ID of the combat the hints are issued in
+	// NOTE: This needs to be echo'd back in the solution
+	CombatID string `json:"combatId"`
 	// Hints for the challenge
 	Hints []interface{} `json:"hints"`
 }
@@ -49,6 +68,9 @@ type AttackSolutionEvent struct {
 	SocketEvent
 	// ID of the given challenge
 	TargetID string `json:"targetId"`
+	// ID of the combat received with the hints
+	// NOTE: The latest combat on the challenge is used if it is empty
+	CombatID string `json:"combatId"`
 	// Hints received in the previous event
 	Hints []interface{} `json:"hints"`
 	// Solutions for the hints
@@ -60,16 +82,28 @@ type AttackResultEvent struct {
 	SocketEvent
 	// ID of the challenge
 	TargetID string `json:"targetId"`
+	// ID of the combat
+	CombatID string `json:"combatId"`
 	// Result of the attack
 	Success bool `json:"success"`
 	// Optional message to pass on from the defender
 	Message string `json:"message"`
 }
 
+// Codes of the errors which can be retried later
+const ErrorCodeRateLimited = "rate_limited"
+const ErrorCodeCooldown = "cooldown"
+
 // Sent when an error happens during an action
 type ErrorEvent struct {
 	SocketEvent
 	Message string `json:"message"`
+	// Code of the error, empty for generic errors
+	Code string `json:"code,omitempty"`
+	// ID of the challenge the error is about
+	TargetID string `json:"targetId,omitempty"`
+	// Milliseconds to wait before retrying the action
+	RetryAfter int64 `json:"retryAfter,omitempty"`
 }
 
 // Happens when a defender is not online to provide
@@ -78,6 +112,8 @@ type DefenderFailedToDefendEvent struct {
 	SocketEvent
 	// ID of the challenge
 	TargetID string `json:"targetId"`
+	// ID of the combat
+	CombatID string `json:"combatId"`
 }
 
 // Happens when an attacker cannot provide solutions for hints
@@ -140,3 +176,52 @@ type SolutionEvaluationEvent struct {
 	// Optional message to pass on the challenger
 	Message string `json:"message"`
 }
+
+// Happens when a player did not answer in time
+// and the combat was closed because of it
+type CombatTimeoutEvent struct {
+	SocketEvent
+	// ID of the challenge
+	TargetID string `json:"targetId"`
+	// ID of the given combat
+	CombatID string `json:"combatId"`
+	// Final state of the combat
+	State string `json:"state"`
+}
+
+// Happens when the game moves into a new phase
+// and right after joining the game
+type GamePhaseEvent struct {
+	SocketEvent
+	// Current phase of the game
+	Phase string `json:"phase"`
+	// Phase before the change
+	Previous string `json:"previous"`
+}
+
+// Happens when the end of the game is getting close
+type CountdownEvent struct {
+	SocketEvent
+	// Seconds left from the game
+	RemainingSeconds int `json:"remainingSeconds"`
+}
+
+// Happens when the player joined from a new session
+// and this session is closed
+type SessionReplacedEvent struct {
+	SocketEvent
+	Message string `json:"message"`
+}
+
+// Happens when the verification of a newly installed
+// challenge is over
+type ChallengeVerificationEvent struct {
+	SocketEvent
+	// ID of the challenge
+	TargetID string `json:"targetId"`
+	// Indicates if the challenge passed the verification
+	// NOTE: Challenges failing the verification are removed
+	Success bool `json:"success"`
+	// Reason of the failure
+	Message string `json:"message"`
+}
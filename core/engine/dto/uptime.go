@@ -0,0 +1,18 @@
+package dto
+
+// Describes the uptime of a single defender
+type DefenderUptimeDTO struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+	// Percentage of time spent online since the first installed challenge
+	Uptime int `json:"uptime"`
+}
+
+// Describes the uptime response
+type UptimeResponse struct {
+	CenturionResponse
+	// Overall uptime of the defender team in percentages
+	Team      int                 `json:"team"`
+	Defenders []DefenderUptimeDTO `json:"defenders"`
+}
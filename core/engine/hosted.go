@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/logger"
+	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/sirupsen/logrus"
+)
+
+// Hosted challenges come with a WebAssembly module which generates the hints
+// and evaluates the solutions in the sandbox whenever the creator is not there to do it
+// The creator can opt to serve the attacks while being online (live evaluation),
+// the module only takes over when the creator is offline
+// NOTE: The module is verified with the example of the challenge right away,
+// the creator does not have to be online for it
+
+func (s *Service) ValidateModule(module []byte) error {
+	return s.sandboxService.Validate(module)
+}
+
+// Returns true if the attacks on a given challenge are served by its module
+func (s *Service) isServedByModule(target challenge.Model) bool {
+	return target.IsHosted() && !(target.LiveEvaluation && s.isConnected(target.CreatorID))
+}
+
+// Verifies a hosted challenge by evaluating its example with its module
+func (s *Service) verifyHostedChallenge(target challenge.Model) error {
+	probe := combat.Model{
+		ID:               uuid.NewString(),
+		Type:             combat.CombatTypeVerification,
+		ChallengeID:      target.ID,
+		ChallengeVersion: target.Version,
+		AttackerID:       combat.SystemAttackerID,
+		DefenderID:       target.CreatorID,
+		CombatState:      combat.CombatStateAttackInitiated,
+	}
+	if err := s.combatService.AddCombat(probe); err != nil {
+		return err
+	}
+	if _, err := s.sandboxService.GenerateHints(target.Module); err != nil {
+		s.failVerification(probe, fmt.Sprintf("Module could not generate hints: %s", err))
+		return nil
+	}
+	if isValid, err := s.sandboxService.Evaluate(target.Module, target.Example.Hints, target.Example.Solutions); err != nil || !isValid {
+		s.failVerification(probe, "The example solution was rejected")
+		return nil
+	}
+	if isValid, err := s.sandboxService.Evaluate(target.Module, target.Example.Hints, corruptSolutions(target.Example.Solutions)); err == nil && isValid {
+		s.failVerification(probe, "A corrupted example solution was accepted")
+		return nil
+	}
+	if _, err := s.combatService.UpdateCombatState(probe.ID, combat.CombatStateDefenseSucceeded); err != nil {
+		logger.LogError(err)
+	}
+	s.publishChallenge(target)
+	return nil
+}
+
+// Starts a combat on a hosted challenge with the hints of its module
+func (s *Service) startHostedCombat(ID string, target challenge.Model) (isConnectionStillAlive bool) {
+	s.supersedeCombats(ID, target)
+	newCombat := combat.Model{
+		ID:               uuid.NewString(),
+		Type:             combat.CombatTypeAttack,
+		ChallengeID:      target.ID,
+		ChallengeVersion: target.Version,
+		AttackerID:       ID,
+		DefenderID:       target.CreatorID,
+		CombatState:      combat.CombatStateAttackInitiated,
+		ServedByModule:   true,
+	}
+	if err := s.combatService.AddCombat(newCombat); err != nil {
+		logger.LogError(err)
+		return s.sendError(ID, "Combat could not be created, please try again")
+	}
+	hints, err := s.sandboxService.GenerateHints(target.Module)
+	if err != nil {
+		return s.failHostedCombat(newCombat, err)
+	}
+	if _, err = s.combatService.SetIssuedHints(newCombat.ID, hints); err != nil {
+		logger.LogError(err)
+	}
+	if _, err = s.combatService.UpdateCombatState(newCombat.ID, combat.CombatStateAttackerChallenged); err != nil {
+		logger.LogError(err)
+	}
+	if attacker, err := s.playerService.FindByID(ID); err == nil {
+		s.bus.Send(&bus.BusEvent{
+			Type: bus.EventTypeAttackInitiated,
+			Information: bus.AttackInitiatedEvent{
+				AttackerName:  attacker.Name,
+				ChallengeName: target.Name,
+			},
+		})
+	}
+	return s.sendResponseOrBreakConnection(ID, dto.AttackChallengeEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeAttackChallenge,
+		},
+		TargetID: target.ID,
+		CombatID: newCombat.ID,
+		Hints:    hints,
+	})
+}
+
+// Evaluates the solutions of an attacker with the module of a hosted challenge
+// NOTE: The module of the version the combat is fought on is used
+func (s *Service) evaluateHostedSolution(m combat.Model, solutions []interface{}) (isConnectionStillAlive bool) {
+	fought, err := s.challengeService.FindVersion(m.ChallengeID, m.ChallengeVersion)
+	if err != nil {
+		logger.LogError(err)
+		return s.sendError(m.AttackerID, "Challenge could not be retrieved")
+	}
+	attacker, err := s.playerService.FindByID(m.AttackerID)
+	if err != nil {
+		logger.LogError(err)
+		return s.sendError(m.AttackerID, "Attacker could not be retrieved")
+	}
+	isValid, err := s.sandboxService.Evaluate(fought.Module, m.Hints, solutions)
+	if err != nil {
+		return s.rejectHostedSolution(m, err)
+	}
+	if err = s.finishEvaluation(m.DefenderID, m, attacker, isValid, ""); err != nil {
+		logger.LogError(err)
+		return s.sendError(m.AttackerID, "Combat is already over")
+	}
+	return s.isConnected(m.AttackerID)
+}
+
+// Closes a combat in which the module of a hosted challenge failed to evaluate
+// the solutions as a rejected solution without any points
+// NOTE: The attacker controls the solutions so the failure can not be blamed on the creator
+func (s *Service) rejectHostedSolution(m combat.Model, reason error) (isConnectionStillAlive bool) {
+	logrus.Warnf("Module of %s failed to evaluate in %s: %s", m.ChallengeID, m.ID, reason)
+	if _, err := s.combatService.CompareAndUpdateCombatState(m.ID, m.CombatState, combat.CombatStateAttackFailed); err != nil {
+		logger.LogError(err)
+		return s.sendError(m.AttackerID, "Combat is already over")
+	}
+	s.limiter.fail(m.AttackerID, m.ChallengeID)
+	return s.sendResponseOrBreakConnection(m.AttackerID, dto.AttackResultEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeAttackResult,
+		},
+		TargetID: m.ChallengeID,
+		CombatID: m.ID,
+		Success:  false,
+		Message:  "Solutions could not be evaluated",
+	})
+}
+
+// Closes a combat in which the module of a hosted challenge failed to generate hints
+// the same way as if its creator failed to defend
+func (s *Service) failHostedCombat(m combat.Model, reason error) (isConnectionStillAlive bool) {
+	logrus.Warnf("Module of %s failed in %s: %s", m.ChallengeID, m.ID, reason)
+	if _, err := s.combatService.CompareAndUpdateCombatState(m.ID, m.CombatState, combat.CombatStateDefenseFailed); err != nil {
+		logger.LogError(err)
+		return s.sendError(m.AttackerID, "Combat is already over")
+	}
+	if err := s.scoreService.AddPoint(m.AttackerID, s.scoreService.GetRules().Attacker.DefenderFailed, scoreboard.Reason{
+		Code:        scoreboard.ReasonCodeModuleFailed,
+		CombatID:    m.ID,
+		ChallengeID: m.ChallengeID,
+		Message:     "Module of the challenge failed",
+	}); err != nil {
+		logger.LogError(err)
+	}
+	return s.sendResponseOrBreakConnection(m.AttackerID, dto.DefenderFailedToDefendEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeDefenderFailedToDefend,
+		},
+		TargetID: m.ChallengeID,
+		CombatID: m.ID,
+	})
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// Describes the limits of the attacks an attacker can launch
+type RateLimits struct {
+	// Window the attacks are counted in
+	Window time.Duration
+	// Attacks an attacker can launch in a window
+	// NOTE: Zero disables the limit
+	AttackerLimit int
+	// Attacks an attacker can launch on a single challenge in a window
+	// NOTE: Zero disables the limit
+	ChallengeLimit int
+	// Time an attacker has to wait before attacking a challenge again
+	// after a rejected solution
+	// NOTE: Zero disables the cooldown
+	FailureCooldown time.Duration
+}
+
+// Reasons of a rejected attack
+const rateLimitReasonAttacker = "attacker"
+const rateLimitReasonChallenge = "challenge"
+const rateLimitReasonCooldown = "cooldown"
+
+// Keeps track of the attacks in the current window
+// and the cooldowns of the attackers
+type rateLimiter struct {
+	limits RateLimits
+	// Returns the current time
+	now func() time.Time
+	// Launch times of the attacks in the window by attacker
+	attacks map[string][]time.Time
+	// Launch times of the attacks in the window by attacker and challenge
+	challengeAttacks map[rateLimitKey][]time.Time
+	// End of the cooldowns by attacker and challenge
+	cooldowns map[rateLimitKey]time.Time
+	mux       sync.Mutex
+}
+
+// Identifies the attacks of an attacker on a challenge
+type rateLimitKey struct {
+	attackerID  string
+	challengeID string
+}
+
+// Returns a limiter enforcing given limits
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits:           limits,
+		now:              time.Now,
+		attacks:          map[string][]time.Time{},
+		challengeAttacks: map[rateLimitKey][]time.Time{},
+		cooldowns:        map[rateLimitKey]time.Time{},
+	}
+}
+
+// Registers an attack of a given attacker on a given challenge if the limits allow it
+// otherwise returns the reason of the rejection and the time to wait before retrying
+func (r *rateLimiter) allow(attackerID string, challengeID string) (reason string, retryAfter time.Duration, ok bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	now := r.now()
+	key := rateLimitKey{attackerID, challengeID}
+	if until, ok := r.cooldowns[key]; ok {
+		if until.After(now) {
+			return rateLimitReasonCooldown, until.Sub(now), false
+		}
+		delete(r.cooldowns, key)
+	}
+	attacks := r.prune(r.attacks[attackerID], now)
+	challengeAttacks := r.prune(r.challengeAttacks[key], now)
+	if r.limits.AttackerLimit > 0 && len(attacks) >= r.limits.AttackerLimit {
+		r.attacks[attackerID] = attacks
+		return rateLimitReasonAttacker, attacks[len(attacks)-r.limits.AttackerLimit].Add(r.limits.Window).Sub(now), false
+	}
+	if r.limits.ChallengeLimit > 0 && len(challengeAttacks) >= r.limits.ChallengeLimit {
+		r.challengeAttacks[key] = challengeAttacks
+		return rateLimitReasonChallenge, challengeAttacks[len(challengeAttacks)-r.limits.ChallengeLimit].Add(r.limits.Window).Sub(now), false
+	}
+	if r.limits.AttackerLimit > 0 {
+		r.attacks[attackerID] = append(attacks, now)
+	}
+	if r.limits.ChallengeLimit > 0 {
+		r.challengeAttacks[key] = append(challengeAttacks, now)
+	}
+	return "", 0, true
+}
+
+// Starts the cooldown of a given attacker on a given challenge
+// after a rejected solution
+func (r *rateLimiter) fail(attackerID string, challengeID string) {
+	if r.limits.FailureCooldown <= 0 {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.cooldowns[rateLimitKey{attackerID, challengeID}] = r.now().Add(r.limits.FailureCooldown)
+}
+
+// Returns the given launch times without the ones
+// which are out of the window
+func (r *rateLimiter) prune(launches []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(launches) && !launches[i].Add(r.limits.Window).After(now) {
+		i++
+	}
+	return launches[i:]
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(RateLimits{
+		Window:          time.Minute,
+		AttackerLimit:   3,
+		ChallengeLimit:  2,
+		FailureCooldown: 10 * time.Second,
+	})
+	limiter.now = func() time.Time { return now }
+
+	// The limit of a single challenge is reached first
+	_, _, ok := limiter.allow("yyy", "a")
+	assert.True(t, ok)
+	now = now.Add(10 * time.Second)
+	_, _, ok = limiter.allow("yyy", "a")
+	assert.True(t, ok)
+	reason, retryAfter, ok := limiter.allow("yyy", "a")
+	assert.False(t, ok)
+	assert.Equal(t, rateLimitReasonChallenge, reason)
+	assert.Equal(t, 50*time.Second, retryAfter)
+
+	// Rejected attacks do not count, the limit of the attacker is reached next
+	_, _, ok = limiter.allow("yyy", "b")
+	assert.True(t, ok)
+	reason, retryAfter, ok = limiter.allow("yyy", "c")
+	assert.False(t, ok)
+	assert.Equal(t, rateLimitReasonAttacker, reason)
+	assert.Equal(t, 50*time.Second, retryAfter)
+	_, _, ok = limiter.allow("zzz", "a")
+	assert.True(t, ok)
+
+	// Attacks leave the window
+	now = now.Add(50 * time.Second)
+	_, _, ok = limiter.allow("yyy", "a")
+	assert.True(t, ok)
+
+	// Rejected solutions start a cooldown on the challenge
+	limiter.fail("zzz", "a")
+	reason, retryAfter, ok = limiter.allow("zzz", "a")
+	assert.False(t, ok)
+	assert.Equal(t, rateLimitReasonCooldown, reason)
+	assert.Equal(t, 10*time.Second, retryAfter)
+	_, _, ok = limiter.allow("zzz", "b")
+	assert.True(t, ok)
+	now = now.Add(10 * time.Second)
+	_, _, ok = limiter.allow("zzz", "a")
+	assert.True(t, ok)
+
+	// Zero limits disable the limiter
+	unlimited := newRateLimiter(RateLimits{})
+	for i := 0; i < 100; i++ {
+		unlimited.fail("yyy", "a")
+		_, _, ok = unlimited.allow("yyy", "a")
+		assert.True(t, ok)
+	}
+}
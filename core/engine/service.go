@@ -1,9 +1,11 @@
 package engine
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/uuid"
@@ -12,8 +14,11 @@ import (
 	"github.com/riltech/centurion/core/challenge"
 	"github.com/riltech/centurion/core/combat"
 	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/game"
 	"github.com/riltech/centurion/core/logger"
 	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/sandbox"
 	"github.com/riltech/centurion/core/scoreboard"
 	"github.com/sirupsen/logrus"
 )
@@ -24,6 +29,44 @@ type IService interface {
 	Join(dto.JoinEvent, *websocket.Conn) error
 	// Triggers all calculations for the end result of the game
 	FinishGame()
+	// Starts the background processes of the engine
+	Start()
+	// Stops the background processes of the engine
+	Stop()
+	// Closes the connection of a given player
+	Kick(ID string) error
+	// Moves a given combat into a final state and notifies the players
+	EndCombat(ID string, state string) (combat.Model, error)
+	// Starts the verification of a challenge waiting for it
+	// NOTE: If the creator is offline the verification starts when the creator joins
+	VerifyChallenge(ID string) error
+	// Sends the example of a published challenge to its creator for evaluation
+	// to check if the creator evaluates the solutions deterministically
+	AuditChallenge(ID string) error
+	// Checks if a given WebAssembly module can serve a challenge
+	ValidateModule(module []byte) error
+}
+
+// Describes the tunable behaviour of the engine service
+type Settings struct {
+	// Describes what happens when a player joins
+	// while having an active session
+	// Either "reject" or "replace"
+	SessionPolicy string
+	// Interval of sending ping frames to the players
+	// NOTE: Zero disables the heartbeat
+	HeartbeatInterval time.Duration
+	// Time a player has to answer a ping (or send any message)
+	// before the player is considered offline
+	HeartbeatTimeout time.Duration
+	// Interval of picking defenders for a determinism audit
+	// NOTE: Zero disables the audits
+	AuditInterval time.Duration
+	// Chance of a connected defender being audited in an interval
+	// values are between 0-1
+	AuditChance float64
+	// Limits of the attacks an attacker can launch
+	RateLimits RateLimits
 }
 
 // Service implementation
@@ -33,9 +76,25 @@ type Service struct {
 	challengeService challenge.IService
 	combatService    combat.IService
 	scoreService     scoreboard.IService
+	gameService      game.IService
+	presenceService  presence.IService
+	sandboxService   sandbox.IService
 
-	activeConnections map[string]*websocket.Conn
+	settings Settings
+
+	activeConnections map[string]*session
 	mux               sync.RWMutex
+
+	// Makes sure the results are only calculated once
+	finishOnce sync.Once
+	// Serializes the scoring of the evaluations
+	evaluations sync.Mutex
+	// Enforces the rate limits of the attacks
+	limiter *rateLimiter
+	// Stops the background audits
+	stopAuditor    chan uint8
+	phaseChangedCh <-chan *bus.BusEvent
+	countdownCh    <-chan *bus.BusEvent
 }
 
 // Interface check
@@ -45,13 +104,46 @@ func (s *Service) Join(event dto.JoinEvent, conn *websocket.Conn) error {
 	if conn == nil {
 		return fmt.Errorf("%s user socket is empty", event.ID)
 	}
-	updated, err := s.playerService.SetPlayerOnlineStatus(event.ID, true)
-	if err != nil {
-		return err
+	heartbeatTimeout := time.Duration(0)
+	if s.settings.HeartbeatInterval > 0 {
+		heartbeatTimeout = s.settings.HeartbeatTimeout
 	}
+	current := newSession(conn, heartbeatTimeout)
 	s.mux.Lock()
-	s.activeConnections[event.ID] = conn
+	previous := s.activeConnections[event.ID]
+	if previous != nil && s.settings.SessionPolicy == SessionPolicyReject {
+		s.mux.Unlock()
+		return ErrSessionAlreadyActive
+	}
+	s.activeConnections[event.ID] = current
 	s.mux.Unlock()
+	if previous != nil {
+		// The read loop of the previous session stops as the socket closes
+		// it does not touch the new session as it is not the active one anymore
+		if err := previous.WriteJSON(dto.SessionReplacedEvent{
+			SocketEvent: dto.SocketEvent{
+				Type: dto.SocketEventTypeSessionReplaced,
+			},
+			Message: "You joined from another session",
+		}); err != nil {
+			logger.LogError(err)
+		}
+		if err := previous.Close(dto.CloseCodeSessionReplaced, "Session replaced"); err != nil {
+			logger.LogError(err)
+		}
+	}
+	updated, err := s.setOnlineStatus(event.ID, true)
+	if err != nil {
+		s.closeSession(event.ID, current)
+		return err
+	}
+	conn.SetPongHandler(func(string) error {
+		return s.markSeen(event.ID, current)
+	})
+	if err = current.ExtendDeadline(); err != nil {
+		logger.LogError(err)
+	}
+	go s.heartbeat(event.ID, current)
 	s.bus.Send(&bus.BusEvent{
 		Type: bus.EventTypePlayerJoined,
 		Information: bus.PlayerJoinedEvent{
@@ -59,10 +151,19 @@ func (s *Service) Join(event dto.JoinEvent, conn *websocket.Conn) error {
 			Team: updated.Team,
 		},
 	})
+	phase := s.gameService.GetPhase()
+	s.sendResponseOrBreakConnection(event.ID, dto.GamePhaseEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeGamePhase,
+		},
+		Phase:    phase,
+		Previous: phase,
+	})
 	if updated.Team == player.TeamTypeAttacker {
-		return s.attacker(event.ID)
+		return s.attacker(event.ID, current)
 	}
-	return s.defender(event.ID)
+	s.verifyPendingChallenges(event.ID)
+	return s.defender(event.ID, current)
 }
 
 // Sends an error message via the socket connection
@@ -70,93 +171,78 @@ func (s *Service) Join(event dto.JoinEvent, conn *websocket.Conn) error {
 // returns status [true] if the connection is alive
 // returns [false] if the connection was terminated
 func (s *Service) sendError(ID string, message string) (isConnectionStillAlive bool) {
-	defer func(alive *bool) {
-		if *alive {
-			return
-		}
-		if _, err := s.playerService.SetPlayerOnlineStatus(ID, false); err != nil {
-			logger.LogError(err)
-		}
-	}(&isConnectionStillAlive)
 	if s == nil {
-		isConnectionStillAlive = false
-		return
+		return false
 	}
-	s.mux.RLock()
-	conn, ok := s.activeConnections[ID]
-	if !ok {
-		s.mux.RUnlock()
-		isConnectionStillAlive = false
-		return
-	}
-	s.mux.RUnlock()
-
-	if err := conn.WriteJSON(dto.ErrorEvent{
+	return s.sendResponseOrBreakConnection(ID, dto.ErrorEvent{
 		SocketEvent: dto.SocketEvent{
 			Type: dto.SocketEventTypeError,
 		},
 		Message: message,
-	}); err != nil {
-		logger.LogError(err)
-		s.closeConnection(ID)
-		isConnectionStillAlive = false
-		return
+	})
+}
+
+// Sends an error about an attack rejected by the rate limits
+// with the time the attacker has to wait before retrying
+func (s *Service) sendRateLimitError(ID string, targetID string, reason string, retryAfter time.Duration) (isConnectionStillAlive bool) {
+	code, message := dto.ErrorCodeRateLimited, "Too many attacks"
+	switch reason {
+	case rateLimitReasonChallenge:
+		message = "Too many attacks on this challenge"
+	case rateLimitReasonCooldown:
+		code, message = dto.ErrorCodeCooldown, "Solution was rejected recently, wait before attacking this challenge again"
 	}
-	isConnectionStillAlive = true
-	return
+	return s.sendResponseOrBreakConnection(ID, dto.ErrorEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeError,
+		},
+		Message:  message,
+		Code:     code,
+		TargetID: targetID,
+		// Rounded up so retrying right after the wait is accepted
+		RetryAfter: int64((retryAfter + time.Millisecond - 1) / time.Millisecond),
+	})
 }
 
 // This function sends a response to the socket
 // or if it is not alive anymore it breaks the connection
 func (s *Service) sendResponseOrBreakConnection(ID string, message interface{}) (isConnectionStillAlive bool) {
-	defer func(alive *bool) {
-		if *alive {
-			return
-		}
-		if _, err := s.playerService.SetPlayerOnlineStatus(ID, false); err != nil {
-			logger.LogError(err)
-		}
-	}(&isConnectionStillAlive)
 	s.mux.RLock()
 	conn, ok := s.activeConnections[ID]
-	if !ok {
-		s.mux.RUnlock()
-		s.closeConnection(ID)
-		isConnectionStillAlive = false
-		return
-	}
 	s.mux.RUnlock()
+	if !ok || conn == nil {
+		if _, err := s.setOnlineStatus(ID, false); err != nil {
+			logger.LogError(err)
+		}
+		return false
+	}
 	if err := conn.WriteJSON(message); err != nil {
-		s.closeConnection(ID)
-		isConnectionStillAlive = false
-		return
+		logger.LogError(err)
+		s.closeSession(ID, conn)
+		return false
 	}
-	isConnectionStillAlive = true
-	return
+	return true
 }
 
 // Command set for attackers
-func (s *Service) attacker(ID string) error {
-	s.mux.RLock()
-	conn, ok := s.activeConnections[ID]
-	if !ok {
-		s.mux.RUnlock()
-		return fmt.Errorf("%s user is not available in active players", ID)
-	}
-	s.mux.RUnlock()
+func (s *Service) attacker(ID string, current *session) error {
+	conn := current.conn
 	for {
 		// Acquire message
 		t, b, err := conn.ReadMessage()
 		logrus.Infof("Read message from attacker (%s) %s", ID, string(b))
 		if t == websocket.CloseMessage {
-			s.closeConnection(ID)
+			s.closeSession(ID, current)
 			break
 		}
 		if err != nil {
 			logger.LogError(err)
-			s.closeConnection(ID)
+			s.closeSession(ID, current)
 			break
 		}
+		if err = s.markSeen(ID, current); err != nil {
+			logger.LogError(err)
+		}
 		// Deserialize message
 		var event dto.SocketEvent
 		if err = json.Unmarshal(b, &event); err != nil {
@@ -175,6 +261,12 @@ func (s *Service) attacker(ID string) error {
 				}
 				continue
 			}
+			if err = s.gameService.CanAttack(); err != nil {
+				if stillActive := s.sendError(ID, err.Error()); !stillActive {
+					break
+				}
+				continue
+			}
 			target, err := s.challengeService.FindByID(detailedEvent.TargetID)
 			if err != nil {
 				logger.LogError(err)
@@ -183,6 +275,19 @@ func (s *Service) attacker(ID string) error {
 				}
 				continue
 			}
+			if !target.IsPublished() {
+				if stillActive := s.sendError(ID, getUnavailableReason(target)); !stillActive {
+					break
+				}
+				continue
+			}
+			if reason, retryAfter, ok := s.limiter.allow(ID, target.ID); !ok {
+				logrus.Infof("Attack of %s on %s was rejected by the %s limit", ID, target.ID, reason)
+				if stillActive := s.sendRateLimitError(ID, target.ID, reason, retryAfter); !stillActive {
+					break
+				}
+				continue
+			}
 			if target.Type == challenge.ChallengeTypeDefault {
 				hints, err := s.challengeService.GenerateHintForDefault(target)
 				if err != nil {
@@ -190,6 +295,31 @@ func (s *Service) attacker(ID string) error {
 					if stillActive := s.sendError(ID, err.Error()); !stillActive {
 						break
 					}
+					continue
+				}
+				s.supersedeCombats(ID, target)
+				newCombat := combat.Model{
+					ID:               uuid.NewString(),
+					Type:             combat.CombatTypeAttack,
+					ChallengeID:      target.ID,
+					ChallengeVersion: target.Version,
+					AttackerID:       ID,
+					DefenderID:       combat.SystemDefenderID,
+					CombatState:      combat.CombatStateAttackInitiated,
+				}
+				if err = s.combatService.AddCombat(newCombat); err != nil {
+					logger.LogError(err)
+					if stillActive := s.sendError(ID, "Combat could not be created, please try again"); !stillActive {
+						break
+					}
+					continue
+				}
+				// The system answers right away, only the issued hints are accepted with a solution
+				if _, err = s.combatService.SetIssuedHints(newCombat.ID, hints); err != nil {
+					logger.LogError(err)
+				}
+				if _, err = s.combatService.UpdateCombatState(newCombat.ID, combat.CombatStateAttackerChallenged); err != nil {
+					logger.LogError(err)
 				}
 				if attacker, err := s.playerService.FindByID(ID); err == nil {
 					s.bus.Send(&bus.BusEvent{
@@ -205,6 +335,7 @@ func (s *Service) attacker(ID string) error {
 						Type: dto.SocketEventTypeAttackChallenge,
 					},
 					TargetID: target.ID,
+					CombatID: newCombat.ID,
 					Hints:    hints,
 				}); !isConnectionStillAlive {
 					break
@@ -219,12 +350,21 @@ func (s *Service) attacker(ID string) error {
 				}
 				continue
 			}
+			if s.isServedByModule(target) {
+				if isConnectionStillAlive := s.startHostedCombat(ID, target); !isConnectionStillAlive {
+					break
+				}
+				continue
+			}
+			s.supersedeCombats(ID, target)
 			newCombat := combat.Model{
-				ID:          uuid.NewString(),
-				ChallengeID: target.ID,
-				AttackerID:  ID,
-				DefenderID:  creator.ID,
-				CombatState: combat.CombatStateAttackInitiated,
+				ID:               uuid.NewString(),
+				Type:             combat.CombatTypeAttack,
+				ChallengeID:      target.ID,
+				ChallengeVersion: target.Version,
+				AttackerID:       ID,
+				DefenderID:       creator.ID,
+				CombatState:      combat.CombatStateAttackInitiated,
 			}
 			err = s.combatService.AddCombat(newCombat)
 			if err != nil {
@@ -245,6 +385,7 @@ func (s *Service) attacker(ID string) error {
 						Type: dto.SocketEventTypeDefenderFailedToDefend,
 					},
 					TargetID: target.ID,
+					CombatID: newCombat.ID,
 				}); !isConnectionStillAlive {
 					break
 				}
@@ -281,6 +422,12 @@ func (s *Service) attacker(ID string) error {
 				}
 				continue
 			}
+			if err = s.gameService.CanAttack(); err != nil {
+				if stillActive := s.sendError(ID, err.Error()); !stillActive {
+					break
+				}
+				continue
+			}
 			target, err := s.challengeService.FindByID(detailedEvent.TargetID)
 			if err != nil {
 				if stillActive := s.sendError(ID, "Invalid challenge ID"); !stillActive {
@@ -288,10 +435,42 @@ func (s *Service) attacker(ID string) error {
 				}
 				continue
 			}
+			if !target.IsPublished() {
+				// The challenge was pulled while the combat was ongoing
+				if ongoingCombat, err := s.findSolvedCombat(ID, target.ID, detailedEvent.CombatID); err == nil {
+					if _, err = s.combatService.UpdateCombatState(ongoingCombat.ID, combat.CombatStateAttackFailed); err != nil {
+						logger.LogError(err)
+					}
+				}
+				if stillActive := s.sendError(ID, getUnavailableReason(target)); !stillActive {
+					break
+				}
+				continue
+			}
 			if target.Type == challenge.ChallengeTypeDefault {
+				ongoingCombat, err := s.findSolvedCombat(ID, target.ID, detailedEvent.CombatID)
+				if err != nil {
+					if stillActive := s.sendError(ID, err.Error()); !stillActive {
+						break
+					}
+					continue
+				}
+				if ongoingCombat.CombatState != combat.CombatStateAttackerChallenged {
+					if stillActive := s.sendError(ID, "No hints were issued for the combat yet"); !stillActive {
+						break
+					}
+					continue
+				}
+				if !isSameHints(ongoingCombat.Hints, detailedEvent.Hints) {
+					s.reportCheatAttempt(ID, target, "Hints differ from the issued ones")
+					if stillActive := s.sendError(ID, "Hints do not match the issued hints"); !stillActive {
+						break
+					}
+					continue
+				}
 				isValid, err := s.challengeService.IsValidSolutionToDefaultModule(
 					target,
-					detailedEvent.Hints,
+					ongoingCombat.Hints,
 					detailedEvent.Solutions)
 				if err != nil {
 					if stillActive := s.sendError(ID, err.Error()); !stillActive {
@@ -299,11 +478,24 @@ func (s *Service) attacker(ID string) error {
 					}
 					continue
 				}
+				// Solutions of default modules are worth no individual points
+				// but they count in the statistics like any other combat
+				stateToUpdate := combat.CombatStateDefenseSucceeded
+				if isValid {
+					stateToUpdate = combat.CombatStateAttackSucceeded
+				}
+				if _, err = s.combatService.UpdateCombatState(ongoingCombat.ID, stateToUpdate); err != nil {
+					logger.LogError(err)
+				}
+				if !isValid {
+					s.limiter.fail(ID, target.ID)
+				}
 				if isConnectionStillAlive := s.sendResponseOrBreakConnection(ID, dto.AttackResultEvent{
 					SocketEvent: dto.SocketEvent{
 						Type: dto.SocketEventTypeAttackResult,
 					},
 					TargetID: target.ID,
+					CombatID: ongoingCombat.ID,
 					Success:  isValid,
 				}); !isConnectionStillAlive {
 					break
@@ -329,20 +521,49 @@ func (s *Service) attacker(ID string) error {
 				}
 				continue
 			}
-			ongoingCombat, err := s.combatService.FindByAttackerAndChallenge(ID, target.ID)
+			ongoingCombat, err := s.findSolvedCombat(ID, target.ID, detailedEvent.CombatID)
 			if err != nil {
 				logger.LogError(err)
-				if stillActive := s.sendError(ID, "No combat found, first initiate an attack"); !stillActive {
+				if stillActive := s.sendError(ID, err.Error()); !stillActive {
+					break
+				}
+				continue
+			}
+			if ongoingCombat.CombatState != combat.CombatStateAttackerChallenged {
+				if stillActive := s.sendError(ID, "No hints were issued for the combat yet"); !stillActive {
+					break
+				}
+				continue
+			}
+			if !isSameHints(ongoingCombat.Hints, detailedEvent.Hints) {
+				s.reportCheatAttempt(ID, target, "Hints differ from the ones issued by the defender")
+				if stillActive := s.sendError(ID, "Hints do not match the issued hints"); !stillActive {
+					break
+				}
+				continue
+			}
+			// The party which issued the hints evaluates the solutions
+			if ongoingCombat.ServedByModule {
+				if isConnectionStillAlive := s.evaluateHostedSolution(ongoingCombat, detailedEvent.Solutions); !isConnectionStillAlive {
 					break
 				}
 				continue
 			}
 			if !creator.Online {
-				if _, err = s.combatService.UpdateCombatState(ongoingCombat.ID, combat.CombatStateDefenseFailed); err != nil {
+				if _, err = s.combatService.CompareAndUpdateCombatState(ongoingCombat.ID, ongoingCombat.CombatState, combat.CombatStateDefenseFailed); err != nil {
 					logger.LogError(err)
+					if stillActive := s.sendError(ID, "Combat is already over"); !stillActive {
+						break
+					}
+					continue
 				}
 				// Add 1 point to the attacker
-				if err = s.scoreService.AddPoint(ID, 1); err != nil {
+				if err = s.scoreService.AddPoint(ID, s.scoreService.GetRules().Attacker.DefenderFailed, scoreboard.Reason{
+					Code:        scoreboard.ReasonCodeDefenderOffline,
+					CombatID:    ongoingCombat.ID,
+					ChallengeID: ongoingCombat.ChallengeID,
+					Message:     "Defender was offline",
+				}); err != nil {
 					logger.LogError(err)
 				}
 				if isConnectionStillAlive := s.sendResponseOrBreakConnection(ID, dto.DefenderFailedToDefendEvent{
@@ -350,6 +571,7 @@ func (s *Service) attacker(ID string) error {
 						Type: dto.SocketEventTypeDefenderFailedToDefend,
 					},
 					TargetID: target.ID,
+					CombatID: ongoingCombat.ID,
 				}); !isConnectionStillAlive {
 					break
 				}
@@ -365,7 +587,7 @@ func (s *Service) attacker(ID string) error {
 					},
 					TargetID:  target.ID,
 					Solutions: detailedEvent.Solutions,
-					Hints:     detailedEvent.Hints,
+					Hints:     ongoingCombat.Hints,
 					CombatID:  ongoingCombat.ID,
 				})
 			}
@@ -375,41 +597,412 @@ func (s *Service) attacker(ID string) error {
 	return nil
 }
 
-// Closes a connection gracefully
-func (s *Service) closeConnection(ID string) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
-	if conn, ok := s.activeConnections[ID]; ok {
-		err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(200, "OK"))
-		if err != nil {
+func (s *Service) Start() {
+	// Picks up the phase of a restored game
+	isRunning := s.gameService.GetPhase() == game.PhaseRunning
+	if err := s.presenceService.SetRunning(isRunning); err != nil {
+		logger.LogError(err)
+	}
+	if err := s.combatService.SetRunning(isRunning); err != nil {
+		logger.LogError(err)
+	}
+	s.combatService.StartReaper(s.handleExpiredCombat)
+	s.startAuditor()
+	go s.listen()
+}
+
+// Handles the bus events the engine is interested in
+func (s *Service) listen() {
+	for {
+		select {
+		case value, ok := <-s.phaseChangedCh:
+			if !ok {
+				return
+			}
+			event, err := value.DecodeGamePhaseChangedEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			if err = s.presenceService.SetRunning(event.Phase == game.PhaseRunning); err != nil {
+				logger.LogError(err)
+			}
+			// Combats cannot be answered while the game is not running
+			if err = s.combatService.SetRunning(event.Phase == game.PhaseRunning); err != nil {
+				logger.LogError(err)
+			}
+			s.broadcast(dto.GamePhaseEvent{
+				SocketEvent: dto.SocketEvent{
+					Type: dto.SocketEventTypeGamePhase,
+				},
+				Phase:    event.Phase,
+				Previous: event.Previous,
+			})
+			if event.Phase == game.PhaseFinished {
+				s.FinishGame()
+				s.closeAllConnections()
+			}
+		case value, ok := <-s.countdownCh:
+			if !ok {
+				return
+			}
+			event, err := value.DecodeCountdownEvent()
+			if err != nil {
+				logger.LogError(err)
+				continue
+			}
+			s.broadcast(dto.CountdownEvent{
+				SocketEvent: dto.SocketEvent{
+					Type: dto.SocketEventTypeCountdown,
+				},
+				RemainingSeconds: int(event.Remaining.Seconds()),
+			})
+		}
+	}
+}
+
+// Sends a given message to every connected player
+func (s *Service) broadcast(message interface{}) {
+	s.mux.RLock()
+	IDs := []string{}
+	for ID, conn := range s.activeConnections {
+		if conn != nil {
+			IDs = append(IDs, ID)
+		}
+	}
+	s.mux.RUnlock()
+	for _, ID := range IDs {
+		s.sendResponseOrBreakConnection(ID, message)
+	}
+}
+
+func (s *Service) Stop() {
+	// Downtime of the server does not count in the uptimes
+	if err := s.presenceService.Suspend(); err != nil {
+		logger.LogError(err)
+	}
+	s.combatService.StopReaper()
+	select {
+	case s.stopAuditor <- 1:
+	default:
+	}
+}
+
+// Applies the consequences of a combat which was closed
+// because one of the sides did not answer in time
+func (s *Service) handleExpiredCombat(m combat.Model) {
+	logrus.Infof("Combat expired %s", spew.Sdump(m))
+	if m.IsVerification() {
+		s.failVerification(m, "Defender did not answer in time")
+		return
+	}
+	if m.IsAudit() {
+		s.failAudit(m)
+		return
+	}
+	attacker, err := s.playerService.FindByID(m.AttackerID)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	defender := player.Model{ID: combat.SystemDefenderID, Name: "System"}
+	if !m.IsAgainstSystem() {
+		if defender, err = s.playerService.FindByID(m.DefenderID); err != nil {
+			logger.LogError(err)
+			return
+		}
+	}
+	target, _ := s.challengeService.FindByID(m.ChallengeID)
+	timeoutEvent := dto.CombatTimeoutEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeCombatTimeout,
+		},
+		TargetID: m.ChallengeID,
+		CombatID: m.ID,
+		State:    m.CombatState,
+	}
+	if m.CombatState == combat.CombatStateDefenseFailed {
+		// Add 1 point to the attacker as the defender failed to defend
+		// NOTE: Combats closed while the game is not running score no points
+		if s.gameService.CanAttack() != nil {
+			logrus.Infof("Combat %s is closed without points outside of the running phase", m.ID)
+		} else if err = s.scoreService.AddPoint(attacker.ID, s.scoreService.GetRules().Attacker.DefenderFailed, scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeDefenseTimeout,
+			CombatID:    m.ID,
+			ChallengeID: m.ChallengeID,
+			Message:     "Defender did not answer in time",
+		}); err != nil {
 			logger.LogError(err)
 		}
-		conn.Close()
+		s.sendResponseOrBreakConnection(attacker.ID, dto.DefenderFailedToDefendEvent{
+			SocketEvent: dto.SocketEvent{
+				Type: dto.SocketEventTypeDefenderFailedToDefend,
+			},
+			TargetID: m.ChallengeID,
+			CombatID: m.ID,
+		})
+		s.sendResponseOrBreakConnection(defender.ID, timeoutEvent)
+	} else {
+		if !m.IsAgainstSystem() {
+			s.sendResponseOrBreakConnection(defender.ID, dto.AttackerFailedToAttackEvent{
+				SocketEvent: dto.SocketEvent{
+					Type: dto.SocketEventTypeAttackerFailedToAttack,
+				},
+				TargetID: m.ChallengeID,
+				CombatID: m.ID,
+			})
+		}
+		s.sendResponseOrBreakConnection(attacker.ID, timeoutEvent)
 	}
-	s.activeConnections[ID] = nil
+	s.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeCombatTimeout,
+		Information: bus.CombatTimeoutEvent{
+			AttackerName:  attacker.Name,
+			DefenderName:  defender.Name,
+			ChallengeName: target.Name,
+			State:         m.CombatState,
+		},
+	})
 }
 
-// Command set for defenders
-func (s *Service) defender(ID string) error {
+func (s *Service) Kick(ID string) error {
 	s.mux.RLock()
 	conn, ok := s.activeConnections[ID]
-	if !ok {
-		return fmt.Errorf("%s user is not available in active players", ID)
+	s.mux.RUnlock()
+	if !ok || conn == nil {
+		return fmt.Errorf("%s player is not connected", ID)
+	}
+	s.sendError(ID, "You were disconnected by the facilitator")
+	s.closeSession(ID, conn)
+	return nil
+}
+
+func (s *Service) EndCombat(ID string, state string) (combat.Model, error) {
+	if !combat.IsFinalCombatState(state) {
+		return combat.Model{}, fmt.Errorf("%s is not a final combat state", state)
+	}
+	ended, err := s.combatService.UpdateCombatState(ID, state)
+	if err != nil {
+		return combat.Model{}, err
+	}
+	message := fmt.Sprintf("Combat was ended by the facilitator (%s)", state)
+	if ended.IsVerification() {
+		s.failVerification(ended, message)
+		return ended, nil
+	}
+	if ended.IsAudit() {
+		if state != combat.CombatStateDefenseSucceeded {
+			s.failAudit(ended)
+		}
+		return ended, nil
+	}
+	s.sendResponseOrBreakConnection(ended.AttackerID, dto.AttackResultEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeAttackResult,
+		},
+		TargetID: ended.ChallengeID,
+		CombatID: ended.ID,
+		Success:  state == combat.CombatStateAttackSucceeded,
+		Message:  message,
+	})
+	if !ended.IsAgainstSystem() {
+		s.sendError(ended.DefenderID, fmt.Sprintf("%s: %s", ended.ID, message))
+	}
+	return ended, nil
+}
+
+// Publishes a cheat attempt of a given player on the bus
+func (s *Service) reportCheatAttempt(ID string, target challenge.Model, reason string) {
+	logrus.Warnf("Cheat attempt from %s on %s: %s", ID, target.ID, reason)
+	p, err := s.playerService.FindByID(ID)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	s.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeCheatAttempt,
+		Information: bus.CheatAttemptEvent{
+			PlayerName:    p.Name,
+			ChallengeName: target.Name,
+			Reason:        reason,
+		},
+	})
+}
+
+// Fails the unanswered combats of an attacker on a challenge
+// as a new attack replaces them
+// NOTE: Defenders are notified so they can drop the superseded combats
+func (s *Service) supersedeCombats(ID string, target challenge.Model) {
+	for _, m := range s.combatService.SupersedeCombats(ID, target.ID) {
+		logrus.Infof("%s combat was superseded by a new attack of %s", m.ID, ID)
+		if m.IsAgainstSystem() || m.ServedByModule || !s.isConnected(m.DefenderID) {
+			continue
+		}
+		s.sendResponseOrBreakConnection(m.DefenderID, dto.AttackerFailedToAttackEvent{
+			SocketEvent: dto.SocketEvent{
+				Type: dto.SocketEventTypeAttackerFailedToAttack,
+			},
+			TargetID: m.ChallengeID,
+			CombatID: m.ID,
+		})
+	}
+}
+
+// Returns the ongoing combat of an attacker a solution is sent in
+// NOTE: Solutions without a combat ID are sent in the latest combat on the challenge
+func (s *Service) findSolvedCombat(ID string, targetID string, combatID string) (combat.Model, error) {
+	if combatID == "" {
+		m, err := s.combatService.FindByAttackerAndChallenge(ID, targetID)
+		if err != nil {
+			return combat.Model{}, fmt.Errorf("No combat found, first initiate an attack")
+		}
+		return m, nil
+	}
+	m, err := s.combatService.FindByID(combatID)
+	if err != nil || m.AttackerID != ID || m.ChallengeID != targetID {
+		return combat.Model{}, fmt.Errorf("Invalid combat ID")
+	}
+	if m.IsInFinalState() {
+		return combat.Model{}, fmt.Errorf("Combat is already over, state is: %s", m.CombatState)
+	}
+	return m, nil
+}
+
+// Returns why a given challenge is not available for the attackers
+func getUnavailableReason(m challenge.Model) string {
+	switch m.Status {
+	case challenge.ChallengeStatusDisabled:
+		return "Challenge is disabled"
+	case challenge.ChallengeStatusRetired:
+		return "Challenge is retired"
+	}
+	return "Challenge is waiting for verification"
+}
+
+// Returns true if the hints echoed back by a client
+// are the same as the ones issued by the server
+func isSameHints(issued []interface{}, echoed []interface{}) bool {
+	a, err := json.Marshal(issued)
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(echoed)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
+// Closes every active connection
+func (s *Service) closeAllConnections() {
+	s.mux.RLock()
+	IDs := []string{}
+	for ID, conn := range s.activeConnections {
+		if conn != nil {
+			IDs = append(IDs, ID)
+		}
 	}
 	s.mux.RUnlock()
+	for _, ID := range IDs {
+		s.mux.RLock()
+		conn := s.activeConnections[ID]
+		s.mux.RUnlock()
+		if conn != nil {
+			s.closeSession(ID, conn)
+		}
+	}
+}
+
+// Sets the online status of a player
+// and records it in the presence ledger
+func (s *Service) setOnlineStatus(ID string, online bool) (player.Model, error) {
+	updated, err := s.playerService.SetPlayerOnlineStatus(ID, online)
+	if err != nil {
+		return updated, err
+	}
+	if err = s.presenceService.SetOnlineStatus(ID, online); err != nil {
+		logger.LogError(err)
+	}
+	return updated, nil
+}
+
+// Sends ping frames periodically until the session is closed
+// NOTE: Missing pongs are detected by the read deadline of the session
+func (s *Service) heartbeat(ID string, current *session) {
+	if s.settings.HeartbeatInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.settings.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-current.done:
+			return
+		case <-ticker.C:
+			if err := current.Ping(); err != nil {
+				logger.LogError(err)
+				s.closeSession(ID, current)
+				return
+			}
+		}
+	}
+}
+
+// Records that a given player is alive
+func (s *Service) markSeen(ID string, current *session) error {
+	if err := s.playerService.MarkSeen(ID); err != nil {
+		logger.LogError(err)
+	}
+	return current.ExtendDeadline()
+}
+
+// Closes a given session of a player gracefully
+// NOTE: The player only goes offline if the session is still the active one,
+// a replaced session does not affect the session which replaced it
+func (s *Service) closeSession(ID string, conn *session) {
+	s.mux.Lock()
+	isActive := s.activeConnections[ID] == conn
+	if isActive {
+		delete(s.activeConnections, ID)
+	}
+	s.mux.Unlock()
+	if err := conn.Close(websocket.CloseNormalClosure, "OK"); err != nil {
+		logger.LogError(err)
+	}
+	if !isActive {
+		return
+	}
+	if _, err := s.setOnlineStatus(ID, false); err != nil {
+		logger.LogError(err)
+	}
+	if s.gameService.GetPhase() == game.PhaseFinished {
+		return
+	}
+	// Nobody is going to answer the combats waiting for the player
+	for _, c := range s.combatService.FailCombatsWaitingOn(ID) {
+		s.handleExpiredCombat(c)
+	}
+}
+
+// Command set for defenders
+func (s *Service) defender(ID string, current *session) error {
+	conn := current.conn
 	for {
 		// Acquire message
 		t, b, err := conn.ReadMessage()
 		logrus.Infof("Read message from defender (%s) %s", ID, string(b))
 		if t == websocket.CloseMessage {
-			s.closeConnection(ID)
+			s.closeSession(ID, current)
 			break
 		}
 		if err != nil {
 			logger.LogError(err)
-			s.closeConnection(ID)
+			s.closeSession(ID, current)
 			break
 		}
+		if err = s.markSeen(ID, current); err != nil {
+			logger.LogError(err)
+		}
 		// Deserialize message
 		var event dto.SocketEvent
 		if err = json.Unmarshal(b, &event); err != nil {
@@ -443,6 +1036,23 @@ func (s *Service) defender(ID string) error {
 				}
 				continue
 			}
+			// Only the defender of the combat can act in it
+			if ongoingCombat.DefenderID != ID {
+				if stillActive := s.sendError(ID, "Invalid combat ID"); !stillActive {
+					break
+				}
+				continue
+			}
+			if ongoingCombat.CombatState != combat.CombatStateDefenseRequested {
+				if stillActive := s.sendError(ID, "Hints are not expected for the combat"); !stillActive {
+					break
+				}
+				continue
+			}
+			if ongoingCombat.IsProbe() {
+				s.handleVerificationHints(ongoingCombat, detailedEvent.Hints)
+				continue
+			}
 			attacker, err := s.playerService.FindByID(ongoingCombat.AttackerID)
 			if err != nil {
 				logger.LogError(err)
@@ -453,8 +1063,12 @@ func (s *Service) defender(ID string) error {
 			}
 			if !attacker.Online {
 				logrus.Infof("Attacker is offline %s", spew.Sdump(attacker))
-				if _, err = s.combatService.UpdateCombatState(ongoingCombat.ID, combat.CombatStateAttackFailed); err != nil {
+				if _, err = s.combatService.CompareAndUpdateCombatState(ongoingCombat.ID, ongoingCombat.CombatState, combat.CombatStateAttackFailed); err != nil {
 					logger.LogError(err)
+					if stillActive := s.sendError(ID, "Combat is already over"); !stillActive {
+						break
+					}
+					continue
 				}
 				if isConnectionStillAlive := s.sendResponseOrBreakConnection(ID, dto.AttackerFailedToAttackEvent{
 					SocketEvent: dto.SocketEvent{
@@ -466,8 +1080,15 @@ func (s *Service) defender(ID string) error {
 					break
 				}
 			} else {
-				if _, err = s.combatService.UpdateCombatState(ongoingCombat.ID, combat.CombatStateAttackerChallenged); err != nil {
+				if _, err = s.combatService.SetIssuedHints(ongoingCombat.ID, detailedEvent.Hints); err != nil {
+					logger.LogError(err)
+				}
+				if _, err = s.combatService.CompareAndUpdateCombatState(ongoingCombat.ID, ongoingCombat.CombatState, combat.CombatStateAttackerChallenged); err != nil {
 					logger.LogError(err)
+					if stillActive := s.sendError(ID, "Combat is already over"); !stillActive {
+						break
+					}
+					continue
 				}
 				// if the connection is not alive here that's the problem of the potential
 				// go routine handling the given defender
@@ -476,6 +1097,7 @@ func (s *Service) defender(ID string) error {
 						Type: dto.SocketEventTypeAttackChallenge,
 					},
 					TargetID: ongoingCombat.ChallengeID,
+					CombatID: ongoingCombat.ID,
 					Hints:    detailedEvent.Hints,
 				})
 			}
@@ -504,6 +1126,27 @@ func (s *Service) defender(ID string) error {
 				}
 				continue
 			}
+			// Only the defender of the combat can evaluate solutions in it
+			if ongoingCombat.DefenderID != ID {
+				if stillActive := s.sendError(ID, "Invalid combat ID"); !stillActive {
+					break
+				}
+				continue
+			}
+			if ongoingCombat.CombatState != combat.CombatStateSolutionEvaluationRequested {
+				if stillActive := s.sendError(ID, "Evaluation is not expected for the combat"); !stillActive {
+					break
+				}
+				continue
+			}
+			if ongoingCombat.IsProbe() {
+				if ongoingCombat.IsAudit() {
+					s.handleAuditEvaluation(ongoingCombat, detailedEvent.Success)
+					continue
+				}
+				s.handleVerificationEvaluation(ongoingCombat, detailedEvent.Success)
+				continue
+			}
 			attacker, err := s.playerService.FindByID(ongoingCombat.AttackerID)
 			if err != nil {
 				logger.LogError(err)
@@ -512,52 +1155,12 @@ func (s *Service) defender(ID string) error {
 				}
 				continue
 			}
-			// Add a point for the defender for the successful flow
-			if err = s.scoreService.AddPoint(ID, 1); err != nil {
+			if err = s.finishEvaluation(ID, ongoingCombat, attacker, detailedEvent.Success, detailedEvent.Message); err != nil {
 				logger.LogError(err)
-			}
-			// Here it does not really matter if the attacker is not online
-			// worst case scenario the attacker does not receive the result
-			// of the combat
-			stateToUpdate := combat.CombatStateDefenseSucceeded
-			if detailedEvent.Success {
-				stateToUpdate = combat.CombatStateAttackSucceeded
-				if !s.combatService.IsAttackerCompletedBefore(attacker.ID, detailedEvent.TargetID) {
-					// Add a point for the attacker for the first successful attack
-					if err = s.scoreService.AddPoint(attacker.ID, 1); err != nil {
-						logger.LogError(err)
-					}
-					// Add a point for the attacker if it is module 5 solution (for every 5 unique)
-					if s.combatService.IsFifthUniqueSolution(attacker.ID, detailedEvent.TargetID) {
-						if err = s.scoreService.AddPoint(attacker.ID, 1); err != nil {
-							logger.LogError(err)
-						}
-					}
-
+				if stillActive := s.sendError(ID, "Combat is already over"); !stillActive {
+					break
 				}
 			}
-			if _, err = s.combatService.UpdateCombatState(ongoingCombat.ID, stateToUpdate); err != nil {
-				logger.LogError(err)
-			}
-			target, _ := s.challengeService.FindByID(ongoingCombat.ChallengeID)
-			s.bus.Send(&bus.BusEvent{
-				Type: bus.EventTypeAttackFinished,
-				Information: bus.AttackFinishedEvent{
-					AttackerName:  attacker.Name,
-					ChallengeName: target.Name,
-					Success:       detailedEvent.Success,
-				},
-			})
-			if attacker.Online {
-				s.sendResponseOrBreakConnection(attacker.ID, dto.AttackResultEvent{
-					SocketEvent: dto.SocketEvent{
-						Type: dto.SocketEventTypeAttackResult,
-					},
-					TargetID: ongoingCombat.ChallengeID,
-					Success:  detailedEvent.Success,
-					Message:  detailedEvent.Message,
-				})
-			}
 			continue
 		}
 		continue
@@ -565,70 +1168,265 @@ func (s *Service) defender(ID string) error {
 	return nil
 }
 
+// Scores the evaluation of a solution given by a defender (or the module of a hosted challenge)
+// and notifies the attacker about the result
+// NOTE: The combat has to be in the state it was read in, otherwise
+// it was finished meanwhile (e.g. timed out) and nothing is scored
+func (s *Service) finishEvaluation(defenderID string, m combat.Model, attacker player.Model, success bool, message string) error {
+	// Solutions are only scored while the game is running
+	if reason := s.gameService.CanAttack(); reason != nil {
+		if _, err := s.combatService.CompareAndUpdateCombatState(m.ID, m.CombatState, combat.CombatStateAttackFailed); err != nil {
+			return err
+		}
+		if attacker.Online {
+			s.sendResponseOrBreakConnection(attacker.ID, dto.AttackResultEvent{
+				SocketEvent: dto.SocketEvent{
+					Type: dto.SocketEventTypeAttackResult,
+				},
+				TargetID: m.ChallengeID,
+				CombatID: m.ID,
+				Success:  false,
+				Message:  reason.Error(),
+			})
+		}
+		return nil
+	}
+	rules := s.scoreService.GetRules()
+	// Points are based on the version the combat is fought on
+	target, _ := s.challengeService.FindVersion(m.ChallengeID, m.ChallengeVersion)
+	// Here it does not really matter if the attacker is not online
+	// worst case scenario the attacker does not receive the result
+	// of the combat
+	stateToUpdate := combat.CombatStateDefenseSucceeded
+	if success {
+		stateToUpdate = combat.CombatStateAttackSucceeded
+	}
+	// NOTE: Evaluations are scored one by one (modules of hosted challenges
+	// evaluate concurrently) so the solvers cannot change meanwhile
+	s.evaluations.Lock()
+	isFirstSolution := success && !s.combatService.IsAttackerCompletedBefore(attacker.ID, m.ChallengeID)
+	priorSolvers := s.combatService.GetCompletionMatrix().GetSolverCount(m.ChallengeID)
+	if _, err := s.combatService.CompareAndUpdateCombatState(m.ID, m.CombatState, stateToUpdate); err != nil {
+		s.evaluations.Unlock()
+		return err
+	}
+	if isFirstSolution {
+		s.awardFirstSolution(attacker, target, m, priorSolvers)
+	}
+	s.evaluations.Unlock()
+	isFirstBlood := isFirstSolution && priorSolvers == 0
+	// Add points for the defender for the successful flow
+	// NOTE: The defender takes no part in the combats served by a module
+	if !m.ServedByModule {
+		if err := s.scoreService.AddPoint(defenderID, rules.Scale(rules.Defender.DefenseFlow, target.Difficulty), scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeDefenseFlow,
+			CombatID:    m.ID,
+			ChallengeID: m.ChallengeID,
+			Message:     "Successful defense flow",
+		}); err != nil {
+			logger.LogError(err)
+		}
+	}
+	if !success {
+		s.limiter.fail(attacker.ID, m.ChallengeID)
+	}
+	s.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeAttackFinished,
+		Information: bus.AttackFinishedEvent{
+			AttackerName:  attacker.Name,
+			ChallengeName: target.Name,
+			Success:       success,
+		},
+	})
+	if isFirstBlood {
+		s.bus.Send(&bus.BusEvent{
+			Type: bus.EventTypeFirstBlood,
+			Information: bus.FirstBloodEvent{
+				AttackerName:  attacker.Name,
+				ChallengeName: target.Name,
+			},
+		})
+	}
+	if attacker.Online {
+		s.sendResponseOrBreakConnection(attacker.ID, dto.AttackResultEvent{
+			SocketEvent: dto.SocketEvent{
+				Type: dto.SocketEventTypeAttackResult,
+			},
+			TargetID: m.ChallengeID,
+			CombatID: m.ID,
+			Success:  success,
+			Message:  message,
+		})
+	}
+	return nil
+}
+
+// Gives the points of an attacker solving a challenge for the first time
+// priorSolvers is the number of attackers who solved the challenge before
+func (s *Service) awardFirstSolution(attacker player.Model, target challenge.Model, m combat.Model, priorSolvers int) {
+	rules := s.scoreService.GetRules()
+	points := rules.Attacker.GetFirstSolutionPoints(time.Since(target.CreatedAt), priorSolvers)
+	if err := s.scoreService.AddPoint(attacker.ID, rules.Scale(points, target.Difficulty), scoreboard.Reason{
+		Code:        scoreboard.ReasonCodeFirstSolution,
+		CombatID:    m.ID,
+		ChallengeID: m.ChallengeID,
+		Message:     "First solution of a challenge",
+	}); err != nil {
+		logger.LogError(err)
+	}
+	if priorSolvers == 0 {
+		if err := s.scoreService.AddPoint(attacker.ID, rules.Scale(rules.Attacker.FirstBlood, target.Difficulty), scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeFirstBlood,
+			CombatID:    m.ID,
+			ChallengeID: m.ChallengeID,
+			Message:     "First attacker to solve the challenge",
+		}); err != nil {
+			logger.LogError(err)
+		}
+	}
+	// Add points for the attacker for every Nth unique solution
+	// NOTE: Only the challenges of the defenders count towards the milestones
+	if m.IsAgainstSystem() {
+		return
+	}
+	uniqueSolutions := s.combatService.GetUniqueSolutionCount(attacker.ID, m.ChallengeID)
+	milestone := rules.Attacker.UniqueSolutionMilestone
+	if milestone.IsReached(uniqueSolutions) {
+		if err := s.scoreService.AddPoint(attacker.ID, milestone.Points, scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeFifthUniqueSolution,
+			CombatID:    m.ID,
+			ChallengeID: m.ChallengeID,
+			Message:     fmt.Sprintf("Solved %d unique challenges", uniqueSolutions),
+		}); err != nil {
+			logger.LogError(err)
+		}
+	}
+}
+
 func (s *Service) FinishGame() {
+	s.finishOnce.Do(s.calculateResults)
+}
+
+// Calculates the team awards and freezes the scores
+func (s *Service) calculateResults() {
+	logrus.Info("Calculating the results of the game")
+	defer s.scoreService.Freeze()
+	rules := s.scoreService.GetRules()
 	overallAttackerSuccess := s.combatService.GetOverallAttackerSuccessPrecent(
 		s.challengeService.GetNumberOfUniqueChallenges(),
 	)
-	if overallAttackerSuccess >= 80 {
-		// Add points for attacker team if they were at least 80 percent
-		// successful on challenges
-		s.scoreService.AwardTeam(player.TeamTypeAttacker, 5, "At least 80 percent successful on challenges")
+	if rules.Attacker.TeamSuccess.IsReached(overallAttackerSuccess) {
+		// Add points for attacker team if they were successful
+		// on enough of the challenges
+		s.scoreService.AwardTeam(player.TeamTypeAttacker, rules.Attacker.TeamSuccess.Points, scoreboard.Reason{
+			Code:    scoreboard.ReasonCodeAttackerSuccess,
+			Message: fmt.Sprintf("At least %d percent successful on challenges", rules.Attacker.TeamSuccess.MinPercent),
+		})
 	}
 	// Add points for attackers for every 100% challenges
 	numberOfAttackers := len(s.playerService.GetTeam(player.TeamTypeAttacker))
-	uniqueChallengesSolved := s.combatService.GetNumberOfUniqueCompletionsPerChallenges()
-	scoresToGive := 0
-	for _, numberOfSuccessfulAttackers := range uniqueChallengesSolved {
-		if numberOfSuccessfulAttackers == uint(numberOfAttackers) {
-			scoresToGive++
+	completions := s.combatService.GetCompletionMatrix()
+	challenges := s.challengeService.GetChallenges()
+	scoresToGive := getFullyCompletedChallengeCount(challenges, completions, numberOfAttackers)
+	s.scoreService.AwardTeam(player.TeamTypeAttacker, scoresToGive*rules.Attacker.FullCompletion, scoreboard.Reason{
+		Code:    scoreboard.ReasonCodeFullCompletion,
+		Message: "For every 100 percent challenges",
+	})
+
+	// Add points for defenders for every challenge solved by
+	// at least one attacker but not by too many of them
+	contested := rules.Defender.ContestedChallenge
+	for _, c := range getContestedChallenges(challenges, completions.GetSolverCounts(), numberOfAttackers, contested) {
+		if err := s.scoreService.AddPoint(c.CreatorID, rules.Scale(contested.Points, c.Difficulty), scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeContestedChallenge,
+			ChallengeID: c.ID,
+			Message:     fmt.Sprintf("Challenge solved by at most %d percent of the attackers", contested.MaxSolverPercent),
+		}); err != nil {
+			logger.LogError(err)
 		}
 	}
-	s.scoreService.AwardTeam(player.TeamTypeAttacker, scoresToGive, "For every 100 percent challenges")
 
 	// Add points for defender team for uptime
-	failPercent := s.combatService.GetDefenseFailPercent()
-	uptime := 100 - failPercent
-	fmt.Println(failPercent, uptime)
-	defAward := 1
-	if uptime >= 97 {
-		defAward = 10
-	} else if uptime >= 93 {
-		defAward = 9
-	} else if uptime >= 89 {
-		defAward = 8
-	} else if uptime >= 85 {
-		defAward = 7
-	} else if uptime >= 82 {
-		defAward = 6
-	} else if uptime >= 79 {
-		defAward = 5
-	} else if uptime >= 75 {
-		defAward = 4
-	} else if uptime >= 70 {
-		defAward = 3
-	} else if uptime >= 65 {
-		defAward = 2
-	} else if uptime < 65 {
-		defAward = 1
-	}
-	s.scoreService.AwardTeam(player.TeamTypeDefender, defAward, "For overall uptime")
+	// NOTE: Time after the end of the game does not count
+	if err := s.presenceService.Stop(); err != nil {
+		logger.LogError(err)
+	}
+	uptime, ok := s.presenceService.GetTeamUptime()
+	if !ok {
+		logrus.Info("No defender installed a challenge, skipping the uptime award")
+		return
+	}
+	logrus.Infof("Defender team uptime is %d percent", uptime)
+	s.scoreService.AwardTeam(player.TeamTypeDefender, rules.Defender.GetUptimePoints(uptime), scoreboard.Reason{
+		Code:    scoreboard.ReasonCodeUptime,
+		Message: fmt.Sprintf("For overall uptime of %d percent", uptime),
+	})
+}
+
+// Returns the number of challenges which were solved by every attacker
+func getFullyCompletedChallengeCount(challenges []challenge.Model, completions combat.CompletionMatrix, numberOfAttackers int) int {
+	if numberOfAttackers == 0 {
+		return 0
+	}
+	completed := 0
+	for _, c := range challenges {
+		if completions.GetSolverCount(c.ID) >= numberOfAttackers {
+			completed++
+		}
+	}
+	return completed
+}
+
+// Returns the player created challenges which are contested
+// according to a given rule
+func getContestedChallenges(challenges []challenge.Model, solverCounts map[string]int, numberOfAttackers int, rule scoreboard.ContestedRule) []challenge.Model {
+	contested := []challenge.Model{}
+	if numberOfAttackers == 0 {
+		return contested
+	}
+	for _, c := range challenges {
+		if c.Type != challenge.ChallengeTypePlayerCreated {
+			continue
+		}
+		if rule.IsContested(solverCounts[c.ID], numberOfAttackers) {
+			contested = append(contested, c)
+		}
+	}
+	return contested
 }
 
 // Constructor for engine service
 func NewService(
-	bus bus.IBus,
+	eventBus bus.IBus,
 	playerService player.IService,
 	challengeService challenge.IService,
 	combatService combat.IService,
 	scoreService scoreboard.IService,
+	gameService game.IService,
+	presenceService presence.IService,
+	sandboxService sandbox.IService,
+	settings Settings,
 ) IService {
+	if gameService.GetPhase() == game.PhaseFinished {
+		// The results were calculated in a previous run
+		scoreService.Freeze()
+	}
 	return &Service{
-		bus:               bus,
+		bus:               eventBus,
 		playerService:     playerService,
 		challengeService:  challengeService,
 		combatService:     combatService,
-		activeConnections: make(map[string]*websocket.Conn),
+		settings:          settings,
+		activeConnections: make(map[string]*session),
 		mux:               sync.RWMutex{},
 		scoreService:      scoreService,
+		gameService:       gameService,
+		presenceService:   presenceService,
+		sandboxService:    sandboxService,
+		limiter:           newRateLimiter(settings.RateLimits),
+		finishOnce:        sync.Once{},
+		stopAuditor:       make(chan uint8, 1),
+		phaseChangedCh:    eventBus.Listen(bus.EventTypeGamePhaseChanged),
+		countdownCh:       eventBus.Listen(bus.EventTypeCountdown),
 	}
 }
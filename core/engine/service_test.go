@@ -2,8 +2,17 @@ package engine
 
 import (
 	"encoding/json"
+	"math/rand"
 	"testing"
+	"time"
 
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/game"
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/scoreboard"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,3 +34,211 @@ func TestSerialization(t *testing.T) {
 	assert.Equal(t, e.Name, "asd")
 	assert.Equal(t, e.ID, "25")
 }
+
+func TestIsSameHints(t *testing.T) {
+	var echoed []interface{}
+	assert.Nil(t, json.Unmarshal([]byte(`["abc", 1, {"b": 2, "a": [1, 2]}]`), &echoed))
+	assert.True(t, isSameHints([]interface{}{"abc", 1, map[string]interface{}{"a": []int{1, 2}, "b": 2}}, echoed))
+	assert.False(t, isSameHints([]interface{}{"abc", 2, map[string]interface{}{"a": []int{1, 2}, "b": 2}}, echoed))
+	assert.False(t, isSameHints([]interface{}{"abc"}, echoed))
+	assert.True(t, isSameHints([]interface{}{"abc"}, []interface{}{"abc"}))
+	assert.False(t, isSameHints([]interface{}{"abc"}, []interface{}{"cba"}))
+}
+
+func TestGetContestedChallenges(t *testing.T) {
+	challenges := []challenge.Model{
+		{ID: "a", CreatorID: "john", Type: challenge.ChallengeTypePlayerCreated},
+		{ID: "b", CreatorID: "john", Type: challenge.ChallengeTypePlayerCreated},
+		{ID: "c", CreatorID: "jane", Type: challenge.ChallengeTypePlayerCreated},
+		{ID: "d", CreatorID: "jane", Type: challenge.ChallengeTypePlayerCreated},
+		{ID: "default", Type: challenge.ChallengeTypeDefault},
+	}
+	solverCounts := map[string]int{"a": 1, "b": 2, "c": 3, "default": 1}
+	rule := scoreboard.DefaultRules().Defender.ContestedChallenge
+	IDs := func(contested []challenge.Model) []string {
+		result := []string{}
+		for _, c := range contested {
+			result = append(result, c.ID)
+		}
+		return result
+	}
+
+	// Nobody can solve anything without attackers
+	assert.Empty(t, getContestedChallenges(challenges, map[string]int{}, 0, rule))
+	// Exactly 50 percent still counts, more does not
+	// and unsolved challenges are not awarded
+	assert.Equal(t, []string{"a", "b"}, IDs(getContestedChallenges(challenges, solverCounts, 4, rule)))
+	assert.Equal(t, []string{"a"}, IDs(getContestedChallenges(challenges, solverCounts, 3, rule)))
+	assert.Equal(t, []string{"a", "b", "c"}, IDs(getContestedChallenges(challenges, solverCounts, 6, rule)))
+}
+
+func TestGetFullyCompletedChallengeCount(t *testing.T) {
+	challenges := []challenge.Model{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	completions := combat.CompletionMatrix{
+		"a":       {"x": true, "y": true},
+		"b":       {"x": true},
+		"removed": {"x": true, "y": true},
+	}
+	assert.Equal(t, 0, getFullyCompletedChallengeCount(challenges, completions, 0))
+	assert.Equal(t, 1, getFullyCompletedChallengeCount(challenges, completions, 2))
+	assert.Equal(t, 2, getFullyCompletedChallengeCount(challenges, completions, 1))
+}
+
+func TestAwardFirstSolution(t *testing.T) {
+	playerService := player.NewService(player.NewRepository())
+	attacker := player.Model{ID: "yyy", Name: "Jane", Team: player.TeamTypeAttacker}
+	assert.Nil(t, playerService.AddPlayer(attacker))
+	rules := scoreboard.DefaultRules()
+	rules.Attacker.FirstBlood = 2
+	rules.Attacker.Decay = scoreboard.DecayRule{Mode: scoreboard.DecayModeSolvers, Every: 1, Step: 1}
+	scoreService := scoreboard.NewService(scoreboard.NewRepository(), playerService, rules)
+	s := &Service{
+		playerService: playerService,
+		combatService: combat.NewService(combat.NewRepository(), combat.Timeouts{}),
+		scoreService:  scoreService,
+	}
+	target := challenge.Model{ID: "a", CreatedAt: time.Now()}
+
+	// First blood is worth the bonus as well
+	s.awardFirstSolution(attacker, target, combat.Model{ID: "1", ChallengeID: "a"}, 0)
+	p, _ := playerService.FindByID("yyy")
+	assert.Equal(t, 3, p.Score)
+	codes := []string{}
+	for _, entry := range scoreService.GetPlayerLedger("yyy") {
+		codes = append(codes, entry.Reason.Code)
+	}
+	assert.Equal(t, []string{scoreboard.ReasonCodeFirstSolution, scoreboard.ReasonCodeFirstBlood}, codes)
+
+	// The reward decays with the prior solvers
+	s.awardFirstSolution(attacker, challenge.Model{ID: "b"}, combat.Model{ID: "2", ChallengeID: "b"}, 1)
+	p, _ = playerService.FindByID("yyy")
+	assert.Equal(t, 3, p.Score)
+}
+
+func TestFailAudit(t *testing.T) {
+	playerService := player.NewService(player.NewRepository())
+	for _, p := range []player.Model{
+		{ID: "def", Name: "John", Team: player.TeamTypeDefender},
+		{ID: "x", Name: "Jane", Team: player.TeamTypeAttacker},
+		{ID: "y", Name: "Jack", Team: player.TeamTypeAttacker},
+	} {
+		assert.Nil(t, playerService.AddPlayer(p))
+	}
+	challengeService := challenge.NewService(challenge.NewRepository())
+	assert.Nil(t, challengeService.AddChallenge(challenge.Model{
+		ID:         "a",
+		Name:       "Reverse",
+		CreatorID:  "def",
+		Type:       challenge.ChallengeTypePlayerCreated,
+		Difficulty: challenge.DifficultyHard,
+	}))
+	rules := scoreboard.DefaultRules()
+	rules.Difficulty[challenge.DifficultyHard] = 2
+	scoreService := scoreboard.NewService(scoreboard.NewRepository(), playerService, rules)
+	combatRepository := combat.NewRepository()
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	auditFailedCh := eventBus.Listen(bus.EventTypeAuditFailed)
+	s := &Service{
+		bus:              eventBus,
+		playerService:    playerService,
+		challengeService: challengeService,
+		combatService:    combat.NewService(combatRepository, combat.Timeouts{}),
+		scoreService:     scoreService,
+	}
+	for _, c := range []combat.Model{
+		{ID: "1", Type: combat.CombatTypeAttack, ChallengeID: "a", AttackerID: "x", DefenderID: "def", CombatState: combat.CombatStateDefenseSucceeded},
+		{ID: "2", Type: combat.CombatTypeAttack, ChallengeID: "a", AttackerID: "y", DefenderID: "def", CombatState: combat.CombatStateAttackSucceeded},
+		// Rejected on another version than the audited one
+		{ID: "3", Type: combat.CombatTypeAttack, ChallengeID: "a", ChallengeVersion: 1, AttackerID: "y", DefenderID: "def", CombatState: combat.CombatStateDefenseSucceeded},
+		{ID: "audit", Type: combat.CombatTypeAudit, ChallengeID: "a", AttackerID: combat.SystemAttackerID, DefenderID: "def", CombatState: combat.CombatStateDefenseFailed},
+	} {
+		assert.Nil(t, combatRepository.AddCombat(c))
+		_, err := combatRepository.UpdateCombatState(c.ID, c.CombatState)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, scoreService.AddPoint("def", 10, scoreboard.Reason{Code: scoreboard.ReasonCodeAdminAdjustment}))
+	audit, _ := s.combatService.FindByID("audit")
+
+	// Penalty and the defense flow of the rejected solution are taken
+	// and the rejected attacker is scored as if the defender failed
+	s.failAudit(audit)
+	defender, _ := playerService.FindByID("def")
+	assert.Equal(t, 6, defender.Score)
+	attacker, _ := playerService.FindByID("x")
+	assert.Equal(t, 1, attacker.Score)
+	attacker, _ = playerService.FindByID("y")
+	assert.Equal(t, 0, attacker.Score)
+	event, err := (<-auditFailedCh).DecodeAuditFailedEvent()
+	assert.Nil(t, err)
+	assert.Equal(t, bus.AuditFailedEvent{DefenderName: "John", ChallengeName: "Reverse", RescoredCombats: 1}, *event)
+
+	// Combats are only re-scored once
+	s.failAudit(audit)
+	defender, _ = playerService.FindByID("def")
+	assert.Equal(t, 4, defender.Score)
+	attacker, _ = playerService.FindByID("x")
+	assert.Equal(t, 1, attacker.Score)
+	event, err = (<-auditFailedCh).DecodeAuditFailedEvent()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, event.RescoredCombats)
+}
+
+func TestGetAuditTargets(t *testing.T) {
+	challengeService := challenge.NewService(challenge.NewRepository())
+	for _, c := range []challenge.Model{
+		{ID: "a", Name: "Published", CreatorID: "def", Type: challenge.ChallengeTypePlayerCreated, Status: challenge.ChallengeStatusPublished},
+		{ID: "b", Name: "Disabled", CreatorID: "def", Type: challenge.ChallengeTypePlayerCreated, Status: challenge.ChallengeStatusDisabled},
+		{ID: "c", Name: "Retired", CreatorID: "def", Type: challenge.ChallengeTypePlayerCreated, Status: challenge.ChallengeStatusRetired},
+		{ID: "d", Name: "Pending", CreatorID: "def", Type: challenge.ChallengeTypePlayerCreated, Status: challenge.ChallengeStatusPendingVerification},
+		{ID: "e", Name: "Offline", CreatorID: "other", Type: challenge.ChallengeTypePlayerCreated, Status: challenge.ChallengeStatusPublished},
+	} {
+		assert.Nil(t, challengeService.AddChallenge(c))
+	}
+	s := &Service{
+		challengeService:  challengeService,
+		activeConnections: map[string]*session{"def": {}},
+		settings:          Settings{AuditChance: 1},
+	}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		targets := s.getAuditTargets(random)
+		assert.Len(t, targets, 1)
+		assert.Equal(t, "a", targets[0].ID)
+	}
+}
+
+func TestExpiredCombatOutsideOfRunningPhase(t *testing.T) {
+	playerService := player.NewService(player.NewRepository())
+	for _, p := range []player.Model{
+		{ID: "def", Name: "John", Team: player.TeamTypeDefender},
+		{ID: "x", Name: "Jane", Team: player.TeamTypeAttacker},
+	} {
+		assert.Nil(t, playerService.AddPlayer(p))
+	}
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	gameService := game.NewService(eventBus, game.NewRepository(), 0, nil)
+	s := &Service{
+		bus:              eventBus,
+		playerService:    playerService,
+		challengeService: challenge.NewService(challenge.NewRepository()),
+		combatService:    combat.NewService(combat.NewRepository(), combat.Timeouts{}),
+		scoreService:     scoreboard.NewService(scoreboard.NewRepository(), playerService, scoreboard.DefaultRules()),
+		gameService:      gameService,
+		presenceService:  presence.NewService(presence.NewRepository()),
+	}
+	expired := combat.Model{ID: "1", Type: combat.CombatTypeAttack, ChallengeID: "a", AttackerID: "x", DefenderID: "def", CombatState: combat.CombatStateDefenseFailed}
+
+	// Defenders leaving during a pause do not pay the attackers
+	assert.Nil(t, gameService.SetPhase(game.PhaseRunning))
+	assert.Nil(t, gameService.SetPhase(game.PhasePaused))
+	s.handleExpiredCombat(expired)
+	attacker, _ := playerService.FindByID("x")
+	assert.Equal(t, 0, attacker.Score)
+
+	assert.Nil(t, gameService.SetPhase(game.PhaseRunning))
+	s.handleExpiredCombat(expired)
+	attacker, _ = playerService.FindByID("x")
+	assert.Equal(t, scoreboard.DefaultRules().Attacker.DefenderFailed, attacker.Score)
+}
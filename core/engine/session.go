@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Rejects a new session while the player already has an active one
+const SessionPolicyReject = "reject"
+
+// Closes the active session of the player when a new one joins
+const SessionPolicyReplace = "replace"
+
+// Returned when a player joins while having an active session
+// and the session policy is reject
+var ErrSessionAlreadyActive = fmt.Errorf("Player already has an active session")
+
+// Checks if a given session policy is known
+func IsValidSessionPolicy(policy string) bool {
+	return policy == SessionPolicyReject || policy == SessionPolicyReplace
+}
+
+// Describes the websocket session of a player
+// NOTE: Websocket connections support only one concurrent writer
+// so every write has to go through the session
+type session struct {
+	conn   *websocket.Conn
+	mux    sync.Mutex
+	closed bool
+	// Closed when the session is closed
+	done chan struct{}
+	// Time the player has to answer a ping
+	// NOTE: Zero disables the read deadline
+	heartbeatTimeout time.Duration
+}
+
+// Constructor for a session
+func newSession(conn *websocket.Conn, heartbeatTimeout time.Duration) *session {
+	return &session{
+		conn:             conn,
+		done:             make(chan struct{}),
+		heartbeatTimeout: heartbeatTimeout,
+	}
+}
+
+// Pushes the read deadline of the socket further
+// as the player proved to be alive
+func (s *session) ExtendDeadline() error {
+	if s.heartbeatTimeout <= 0 {
+		return nil
+	}
+	return s.conn.SetReadDeadline(time.Now().Add(s.heartbeatTimeout))
+}
+
+// Sends a ping frame to the player
+// NOTE: Control frames can be written concurrently with other messages
+func (s *session) Ping() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.heartbeatTimeout))
+}
+
+// Makes sure a player who does not read the socket
+// cannot block writes forever
+func (s *session) setWriteDeadline() {
+	if s.heartbeatTimeout <= 0 {
+		return
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(s.heartbeatTimeout))
+}
+
+// Writes a given message as JSON to the socket
+func (s *session) WriteJSON(message interface{}) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.closed {
+		return fmt.Errorf("Session is closed")
+	}
+	s.setWriteDeadline()
+	return s.conn.WriteJSON(message)
+}
+
+// Sends a close frame with a given code and reason
+// then closes the underlying connection
+// NOTE: Closing an already closed session does nothing
+func (s *session) Close(code int, reason string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.setWriteDeadline()
+	err := s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	s.conn.Close()
+	return err
+}
@@ -0,0 +1,288 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/engine/dto"
+	"github.com/riltech/centurion/core/logger"
+	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/sirupsen/logrus"
+)
+
+// Verification of a new challenge consists of two probes
+// 1. A defend action is requested, then the example solution is sent
+//    for evaluation which the defender has to accept
+// 2. A corrupted copy of the example solution is sent for evaluation
+//    which the defender has to reject
+// The challenge is published when both probes pass,
+// otherwise it is removed and the defender can install it again
+// NOTE: Hosted challenges are verified by their module instead (see hosted.go)
+// NOTE: Updates of a challenge are verified as drafts, the current version stays
+// published until the draft passes (and it is kept if the draft fails)
+
+func (s *Service) VerifyChallenge(ID string) error {
+	target, err := s.challengeService.FindDraft(ID)
+	if err != nil {
+		if target, err = s.challengeService.FindByID(ID); err != nil {
+			return err
+		}
+		if target.IsPublished() {
+			return fmt.Errorf("%s challenge is already published", ID)
+		}
+	}
+	if target.IsHosted() {
+		return s.verifyHostedChallenge(target)
+	}
+	if !s.isConnected(target.CreatorID) {
+		logrus.Infof("Verification of %s waits for %s to join", target.ID, target.CreatorID)
+		return nil
+	}
+	// A new session of the creator does not restart an ongoing verification
+	if ongoing, err := s.combatService.FindByAttackerAndChallenge(combat.SystemAttackerID, target.ID); err == nil && ongoing.IsVerification() {
+		return nil
+	}
+	probe := combat.Model{
+		ID:               uuid.NewString(),
+		Type:             combat.CombatTypeVerification,
+		ChallengeID:      target.ID,
+		ChallengeVersion: target.Version,
+		AttackerID:       combat.SystemAttackerID,
+		DefenderID:       target.CreatorID,
+		CombatState:      combat.CombatStateAttackInitiated,
+	}
+	if err = s.combatService.AddCombat(probe); err != nil {
+		return err
+	}
+	if _, err = s.combatService.UpdateCombatState(probe.ID, combat.CombatStateDefenseRequested); err != nil {
+		return err
+	}
+	s.sendResponseOrBreakConnection(target.CreatorID, dto.DefendActionRequestEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeDefendActionRequest,
+		},
+		TargetID: target.ID,
+		CombatID: probe.ID,
+	})
+	return nil
+}
+
+// Starts the verification of every pending challenge of a given defender
+func (s *Service) verifyPendingChallenges(defenderID string) {
+	for _, c := range s.challengeService.GetPendingChallenges(defenderID) {
+		if err := s.VerifyChallenge(c.ID); err != nil {
+			logger.LogError(err)
+		}
+	}
+}
+
+// Handles the hints of the defender in a verification probe
+// by sending the example solution for evaluation
+func (s *Service) handleVerificationHints(m combat.Model, hints []interface{}) {
+	if m.CombatState != combat.CombatStateDefenseRequested {
+		s.sendError(m.DefenderID, "Hints are not expected in the verification of the challenge")
+		return
+	}
+	if len(hints) == 0 {
+		s.failVerification(m, "No hints were provided for the defend action request")
+		return
+	}
+	target, err := s.challengeService.FindVersion(m.ChallengeID, m.ChallengeVersion)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	if _, err = s.combatService.SetIssuedHints(m.ID, hints); err != nil {
+		logger.LogError(err)
+	}
+	s.requestProbeEvaluation(m, target.Example.Hints, target.Example.Solutions)
+}
+
+// Handles the evaluation of the defender in a verification probe
+func (s *Service) handleVerificationEvaluation(m combat.Model, success bool) {
+	if m.CombatState != combat.CombatStateSolutionEvaluationRequested {
+		s.sendError(m.DefenderID, "Evaluation is not expected in the verification of the challenge")
+		return
+	}
+	if m.Type == combat.CombatTypeVerification && !success {
+		s.failVerification(m, "The example solution was rejected")
+		return
+	}
+	if m.Type == combat.CombatTypeVerificationCorrupted && success {
+		s.failVerification(m, "A corrupted example solution was accepted")
+		return
+	}
+	if _, err := s.combatService.UpdateCombatState(m.ID, combat.CombatStateDefenseSucceeded); err != nil {
+		logger.LogError(err)
+		return
+	}
+	target, err := s.challengeService.FindVersion(m.ChallengeID, m.ChallengeVersion)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	if m.Type == combat.CombatTypeVerificationCorrupted {
+		s.publishChallenge(target)
+		return
+	}
+	probe := combat.Model{
+		ID:               uuid.NewString(),
+		Type:             combat.CombatTypeVerificationCorrupted,
+		ChallengeID:      target.ID,
+		ChallengeVersion: target.Version,
+		AttackerID:       combat.SystemAttackerID,
+		DefenderID:       target.CreatorID,
+		CombatState:      combat.CombatStateAttackInitiated,
+	}
+	if err = s.combatService.AddCombat(probe); err != nil {
+		logger.LogError(err)
+		return
+	}
+	s.requestProbeEvaluation(probe, target.Example.Hints, corruptSolutions(target.Example.Solutions))
+}
+
+// Sends a given solution to the defender for evaluation in a verification or audit probe
+func (s *Service) requestProbeEvaluation(m combat.Model, hints []interface{}, solutions []interface{}) {
+	if _, err := s.combatService.UpdateCombatState(m.ID, combat.CombatStateSolutionEvaluationRequested); err != nil {
+		logger.LogError(err)
+		return
+	}
+	s.sendResponseOrBreakConnection(m.DefenderID, dto.SolutionEvaluationRequestEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeSolutionEvaluationRequest,
+		},
+		TargetID:  m.ChallengeID,
+		CombatID:  m.ID,
+		Solutions: solutions,
+		Hints:     hints,
+	})
+}
+
+// Removes a challenge which failed the verification
+// (or the draft of an update) and notifies its creator
+func (s *Service) failVerification(m combat.Model, reason string) {
+	logrus.Infof("%s challenge failed the verification: %s", m.ChallengeID, reason)
+	if !m.IsInFinalState() {
+		if _, err := s.combatService.UpdateCombatState(m.ID, combat.CombatStateDefenseFailed); err != nil {
+			logger.LogError(err)
+		}
+	}
+	if m.ChallengeVersion > 1 {
+		if err := s.challengeService.DiscardDraft(m.ChallengeID); err != nil {
+			logger.LogError(err)
+		}
+	} else if err := s.challengeService.RemoveChallenge(m.ChallengeID); err != nil {
+		logger.LogError(err)
+	}
+	s.sendResponseOrBreakConnection(m.DefenderID, dto.ChallengeVerificationEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeChallengeVerification,
+		},
+		TargetID: m.ChallengeID,
+		Success:  false,
+		Message:  reason,
+	})
+}
+
+// Makes a verified challenge available for the attackers
+func (s *Service) publishChallenge(target challenge.Model) {
+	// The award is only given once, even if the earlier challenges were removed
+	isFirstModule := s.challengeService.IsFirstModule(target) &&
+		!hasReason(s.scoreService.GetPlayerLedger(target.CreatorID), scoreboard.ReasonCodeFirstChallenge)
+	// Updates replace the current version only now, combats
+	// which are already ongoing stay on the version they started with
+	if target.Version > 1 {
+		if _, err := s.challengeService.PublishDraft(target.ID); err != nil {
+			logger.LogError(err)
+			return
+		}
+	} else if _, err := s.challengeService.SetStatus(target.ID, challenge.ChallengeStatusPublished); err != nil {
+		logger.LogError(err)
+		return
+	}
+	verified := dto.ChallengeVerificationEvent{
+		SocketEvent: dto.SocketEvent{
+			Type: dto.SocketEventTypeChallengeVerification,
+		},
+		TargetID: target.ID,
+		Success:  true,
+	}
+	// Updates of a challenge are not installations
+	if target.Version > 1 {
+		logrus.Infof("Version %d of %s challenge is published", target.Version, target.ID)
+		s.sendResponseOrBreakConnection(target.CreatorID, verified)
+		return
+	}
+	defender, err := s.playerService.FindByID(target.CreatorID)
+	if err != nil {
+		logger.LogError(err)
+		return
+	}
+	if isFirstModule {
+		if err = s.scoreService.AddPoint(defender.ID, s.scoreService.GetRules().Defender.FirstChallenge, scoreboard.Reason{
+			Code:        scoreboard.ReasonCodeFirstChallenge,
+			ChallengeID: target.ID,
+			Message:     "First installed challenge",
+		}); err != nil {
+			logger.LogError(err)
+		}
+	}
+	// Defenders are expected to stay online from their first challenge
+	// which is not served by a module
+	if !target.IsHosted() {
+		if err = s.presenceService.StartTracking(defender.ID, defender.Online); err != nil {
+			logger.LogError(err)
+		}
+	}
+	s.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeDefenseModuleInstalled,
+		Information: bus.DefenseModuleInstalledEvent{
+			Name:        target.Name,
+			CreatorName: defender.Name,
+		},
+	})
+	s.sendResponseOrBreakConnection(defender.ID, verified)
+}
+
+// Returns true if a given ledger has an entry with a given reason code
+func hasReason(ledger []scoreboard.Entry, code string) bool {
+	for _, entry := range ledger {
+		if entry.Reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if a given player has an active session
+func (s *Service) isConnected(ID string) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.activeConnections[ID] != nil
+}
+
+// Returns a copy of given solutions in which every value is altered
+func corruptSolutions(solutions []interface{}) []interface{} {
+	corrupted := make([]interface{}, 0, len(solutions)+1)
+	for _, solution := range solutions {
+		switch value := solution.(type) {
+		case string:
+			corrupted = append(corrupted, value+"_corrupted")
+		case float64:
+			corrupted = append(corrupted, value+1)
+		case bool:
+			corrupted = append(corrupted, !value)
+		case nil:
+			corrupted = append(corrupted, "corrupted")
+		default:
+			corrupted = append(corrupted, nil)
+		}
+	}
+	if len(corrupted) == 0 {
+		corrupted = append(corrupted, "corrupted")
+	}
+	return corrupted
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/riltech/centurion/core/auth"
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
+	"github.com/riltech/centurion/core/combat"
+	"github.com/riltech/centurion/core/engine"
+	"github.com/riltech/centurion/core/game"
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/sandbox"
+	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineGameClock(t *testing.T) {
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	countdowns := eventBus.Listen(bus.EventTypeCountdown)
+	changes := eventBus.Listen(bus.EventTypeGamePhaseChanged)
+	playerService := player.NewService(player.NewRepository())
+	gameService := game.NewService(eventBus, game.NewRepository(), 1500*time.Millisecond, []time.Duration{time.Second})
+	sandboxService := sandbox.NewService(sandbox.Limits{Timeout: time.Second, MemoryPages: 256}, 1)
+	defer sandboxService.Close()
+	e := NewEngine(
+		0,
+		eventBus,
+		scoreboard.NewService(scoreboard.NewRepository(), playerService, scoreboard.DefaultRules()),
+		combat.NewService(combat.NewRepository(), combat.Timeouts{}),
+		playerService,
+		challenge.NewService(challenge.NewRepository()),
+		gameService,
+		presence.NewService(presence.NewRepository()),
+		sandboxService,
+		auth.NewService([]byte("secret")),
+		"",
+		engine.Settings{},
+	)
+	assert.Nil(t, gameService.SetPhase(game.PhaseRunning))
+	<-changes
+	go e.Start()
+
+	// The clock of the game runs once the engine is started
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("Countdown was not sent in time")
+	case value := <-countdowns:
+		event, err := value.DecodeCountdownEvent()
+		assert.Nil(t, err)
+		assert.Equal(t, time.Second, event.Remaining)
+	}
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("Game was not finished in time")
+	case value := <-changes:
+		event, err := value.DecodeGamePhaseChangedEvent()
+		assert.Nil(t, err)
+		assert.Equal(t, game.PhaseFinished, event.Phase)
+	}
+	e.Stop()
+}
@@ -0,0 +1,51 @@
+package game
+
+import "time"
+
+const (
+	// Players can register and defenders can install challenges
+	// but attacks are not accepted yet
+	PhaseLobby = "lobby"
+	// Normal play
+	PhaseRunning = "running"
+	// Attacks are rejected until the game is resumed
+	PhasePaused = "paused"
+	// The game is over and the scores are frozen
+	PhaseFinished = "finished"
+)
+
+// Describes the allowed transitions between the phases
+var phaseTransitions = map[string][]string{
+	PhaseLobby:    {PhaseRunning, PhaseFinished},
+	PhaseRunning:  {PhasePaused, PhaseFinished},
+	PhasePaused:   {PhaseRunning, PhaseFinished},
+	PhaseFinished: {},
+}
+
+// Describes the state of the game
+type Model struct {
+	// Current phase of the game
+	Phase string
+	// Time of the last phase change
+	PhaseChangedAt time.Time
+	// Time spent in the running phase before the last phase change
+	Elapsed time.Duration
+}
+
+// Returns the time the game spent running so far
+func (m Model) GetElapsed(now time.Time) time.Duration {
+	if m.Phase != PhaseRunning {
+		return m.Elapsed
+	}
+	return m.Elapsed + now.Sub(m.PhaseChangedAt)
+}
+
+// Returns if the game can move from one phase to the other
+func IsValidTransition(from string, to string) bool {
+	for _, phase := range phaseTransitions[from] {
+		if phase == to {
+			return true
+		}
+	}
+	return false
+}
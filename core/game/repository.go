@@ -0,0 +1,80 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/riltech/centurion/core/logger"
+	"github.com/riltech/centurion/core/storage"
+)
+
+// Key of the game state in the store
+const storeKey = "game"
+
+// Describes a repository for the game state
+type IRepository interface {
+	// Returns the current state of the game
+	Get() Model
+	// Sets the phase of the game
+	SetPhase(phase string) Model
+}
+
+// Game repository implementation
+type Repository struct {
+	mux   sync.RWMutex
+	state Model
+	// Optional persistence, nil when running in memory only
+	store storage.IStore
+}
+
+// Interface check
+var _ IRepository = (*Repository)(nil)
+
+func (r *Repository) Get() Model {
+	if r == nil {
+		return Model{}
+	}
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.state
+}
+
+func (r *Repository) SetPhase(phase string) Model {
+	if r == nil {
+		return Model{}
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	now := time.Now()
+	r.state.Elapsed = r.state.GetElapsed(now)
+	r.state.Phase = phase
+	r.state.PhaseChangedAt = now
+	if r.store != nil {
+		if err := r.store.Save(storeKey, r.state); err != nil {
+			logger.LogError(err)
+		}
+	}
+	return r.state
+}
+
+// Constructor to create a new game repository
+// NOTE: Every game starts in the lobby
+func NewRepository() *Repository {
+	return &Repository{
+		mux: sync.RWMutex{},
+		state: Model{
+			Phase:          PhaseLobby,
+			PhaseChangedAt: time.Now(),
+		},
+	}
+}
+
+// Constructor to create a repository backed by a store
+func NewPersistentRepository(store storage.IStore) (*Repository, error) {
+	r := NewRepository()
+	r.store = store
+	if _, err := store.Load(storeKey, &r.state); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
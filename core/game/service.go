@@ -0,0 +1,179 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/logger"
+)
+
+// Describes a game service interface
+type IService interface {
+	// Returns the current phase of the game
+	GetPhase() string
+	// Moves the game into a given phase
+	// and publishes the change on the bus
+	SetPhase(phase string) error
+	// Returns an error describing why attacks are not accepted
+	// or nil if the game is running
+	CanAttack() error
+	// Returns an error if players cannot register
+	// or install challenges anymore
+	CanJoin() error
+	// Returns the time the game spent running so far
+	GetElapsed() time.Duration
+	// Returns the time left from the game
+	// returns false if the game has no duration limit
+	GetRemaining() (time.Duration, bool)
+	// Starts tracking the game duration in the background
+	// Sends countdown events and finishes the game when the time is up
+	StartClock()
+	// Stops tracking the game duration
+	StopClock()
+}
+
+// Service implementation
+type Service struct {
+	bus        bus.IBus
+	repository IRepository
+	// Makes phase transitions atomic
+	mux sync.Mutex
+
+	// Length of the game, zero means unlimited
+	duration time.Duration
+	// Remaining times when a countdown event is sent
+	countdownMarks []time.Duration
+	stopClock      chan uint8
+}
+
+// Interface check
+var _ IService = (*Service)(nil)
+
+func (s *Service) GetPhase() string {
+	return s.repository.Get().Phase
+}
+
+func (s *Service) SetPhase(phase string) error {
+	s.mux.Lock()
+	previous := s.repository.Get().Phase
+	if !IsValidTransition(previous, phase) {
+		s.mux.Unlock()
+		return fmt.Errorf("Game cannot move from %s to %s", previous, phase)
+	}
+	s.repository.SetPhase(phase)
+	s.mux.Unlock()
+	s.bus.Send(&bus.BusEvent{
+		Type: bus.EventTypeGamePhaseChanged,
+		Information: bus.GamePhaseChangedEvent{
+			Previous: previous,
+			Phase:    phase,
+		},
+	})
+	return nil
+}
+
+func (s *Service) CanAttack() error {
+	switch s.GetPhase() {
+	case PhaseRunning:
+		return nil
+	case PhaseLobby:
+		return fmt.Errorf("The game has not started yet, attacks are not accepted in the lobby")
+	case PhasePaused:
+		return fmt.Errorf("The game is paused, attacks are not accepted until it is resumed")
+	default:
+		return fmt.Errorf("The game is finished, attacks are not accepted anymore")
+	}
+}
+
+func (s *Service) CanJoin() error {
+	if s.GetPhase() == PhaseFinished {
+		return fmt.Errorf("The game is finished")
+	}
+	return nil
+}
+
+func (s *Service) GetElapsed() time.Duration {
+	return s.repository.Get().GetElapsed(time.Now())
+}
+
+func (s *Service) GetRemaining() (time.Duration, bool) {
+	if s.duration <= 0 {
+		return 0, false
+	}
+	remaining := s.duration - s.GetElapsed()
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// Sends countdown events for the marks passed since the last check
+// and finishes the game if the time is up
+// returns the remaining time to compare against in the next check
+func (s *Service) tick(lastRemaining time.Duration) time.Duration {
+	remaining, limited := s.GetRemaining()
+	if !limited || s.GetPhase() != PhaseRunning {
+		return lastRemaining
+	}
+	for _, mark := range s.countdownMarks {
+		if lastRemaining > mark && remaining <= mark && remaining > 0 {
+			s.bus.Send(&bus.BusEvent{
+				Type: bus.EventTypeCountdown,
+				Information: bus.CountdownEvent{
+					Remaining: mark,
+				},
+			})
+		}
+	}
+	if remaining == 0 {
+		if err := s.SetPhase(PhaseFinished); err != nil {
+			logger.LogError(err)
+		}
+	}
+	return remaining
+}
+
+func (s *Service) StartClock() {
+	lastRemaining, limited := s.GetRemaining()
+	if !limited {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopClock:
+				return
+			case <-ticker.C:
+				lastRemaining = s.tick(lastRemaining)
+			}
+		}
+	}()
+}
+
+func (s *Service) StopClock() {
+	select {
+	case s.stopClock <- 1:
+	default:
+	}
+}
+
+// Constructor for the game service
+func NewService(
+	eventBus bus.IBus,
+	repository IRepository,
+	duration time.Duration,
+	countdownMarks []time.Duration,
+) IService {
+	return &Service{
+		bus:            eventBus,
+		repository:     repository,
+		mux:            sync.Mutex{},
+		duration:       duration,
+		countdownMarks: countdownMarks,
+		stopClock:      make(chan uint8, 1),
+	}
+}
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/riltech/centurion/core/bus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServicePhases(t *testing.T) {
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	changes := eventBus.Listen(bus.EventTypeGamePhaseChanged)
+	service := NewService(eventBus, NewRepository(), 0, nil)
+	assert.Equal(t, PhaseLobby, service.GetPhase())
+	assert.NotNil(t, service.CanAttack())
+	assert.Nil(t, service.CanJoin())
+	assert.NotNil(t, service.SetPhase(PhasePaused))
+
+	assert.Nil(t, service.SetPhase(PhaseRunning))
+	event, err := (<-changes).DecodeGamePhaseChangedEvent()
+	assert.Nil(t, err)
+	assert.Equal(t, PhaseLobby, event.Previous)
+	assert.Equal(t, PhaseRunning, event.Phase)
+	assert.Nil(t, service.CanAttack())
+
+	assert.Nil(t, service.SetPhase(PhasePaused))
+	<-changes
+	assert.NotNil(t, service.CanAttack())
+	assert.Nil(t, service.SetPhase(PhaseRunning))
+	<-changes
+
+	assert.Nil(t, service.SetPhase(PhaseFinished))
+	<-changes
+	assert.NotNil(t, service.CanAttack())
+	assert.NotNil(t, service.CanJoin())
+	assert.NotNil(t, service.SetPhase(PhaseFinished))
+	assert.NotNil(t, service.SetPhase(PhaseRunning))
+}
+
+func TestServiceClock(t *testing.T) {
+	eventBus := bus.NewBus()
+	defer eventBus.Stop()
+	countdowns := eventBus.Listen(bus.EventTypeCountdown)
+	changes := eventBus.Listen(bus.EventTypeGamePhaseChanged)
+	repo := NewRepository()
+	service := NewService(eventBus, repo, time.Minute, []time.Duration{30 * time.Second, 5 * time.Second}).(*Service)
+	remaining, limited := service.GetRemaining()
+	assert.True(t, limited)
+	assert.Equal(t, time.Minute, remaining)
+
+	// Time does not pass in the lobby
+	assert.Equal(t, 40*time.Second, service.tick(40*time.Second))
+
+	repo.state = Model{
+		Phase:          PhaseRunning,
+		PhaseChangedAt: time.Now(),
+		Elapsed:        50 * time.Second,
+	}
+	remaining = service.tick(40 * time.Second)
+	assert.True(t, remaining <= 10*time.Second)
+	event, err := (<-countdowns).DecodeCountdownEvent()
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, event.Remaining)
+
+	repo.state.Elapsed = 2 * time.Minute
+	assert.Equal(t, time.Duration(0), service.tick(remaining))
+	change, err := (<-changes).DecodeGamePhaseChangedEvent()
+	assert.Nil(t, err)
+	assert.Equal(t, PhaseFinished, change.Phase)
+	assert.True(t, service.GetElapsed() >= 2*time.Minute)
+
+	unlimited := NewService(eventBus, NewRepository(), 0, nil)
+	_, limited = unlimited.GetRemaining()
+	assert.False(t, limited)
+}
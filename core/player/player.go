@@ -1,5 +1,7 @@
 package player
 
+import "time"
+
 // Describes attacker team type
 const TeamTypeAttacker = "attacker"
 
@@ -20,4 +22,6 @@ type Model struct {
 	Score int
 	// Online indicator
 	Online bool
+	// Last time a message or a heartbeat was received from the player
+	LastSeenAt time.Time
 }
@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/riltech/centurion/core/storage"
 )
 
+// Bucket of the players in the store
+const storeBucket = "players"
+
 // Describes a repository for the engine
 type IRepository interface {
 	// Fetches the available players in the system
@@ -16,14 +22,22 @@ type IRepository interface {
 	UpdateByID(ID string, update Model) (Model, error)
 	// Finds a user by ID
 	FindByID(ID string) (Model, error)
-	// Add given amounts of points to a player
-	AddPoint(ID string, points int) (Model, error)
+	// Sets the score of a player
+	SetScore(ID string, score int) (Model, error)
+	// Sets the online status of a player
+	// NOTE: The last seen time is only updated when the player comes online
+	SetOnlineStatus(ID string, online bool, seenAt time.Time) (Model, error)
+	// Updates the last seen time of a player
+	SetLastSeen(ID string, seenAt time.Time) error
 }
 
 // Engine repository implementation
 type Repository struct {
 	mux     sync.RWMutex
 	players []Model
+	// Optional persistence, nil when running in memory only
+	store         storage.IStore
+	storedPlayers *storage.Collection
 }
 
 // Interface check
@@ -35,9 +49,9 @@ func (r *Repository) AddPlayer(user Model) error {
 	}
 	if r.players == nil {
 		r.mux.Lock()
+		defer r.mux.Unlock()
 		r.players = []Model{user}
-		r.mux.Unlock()
-		return nil
+		return r.persist(user)
 	}
 	r.mux.RLock()
 	for _, p := range r.players {
@@ -48,9 +62,9 @@ func (r *Repository) AddPlayer(user Model) error {
 	}
 	r.mux.RUnlock()
 	r.mux.Lock()
+	defer r.mux.Unlock()
 	r.players = append(r.players, user)
-	r.mux.Unlock()
-	return nil
+	return r.persist(user)
 }
 
 func (r *Repository) GetPlayers() []Model {
@@ -71,9 +85,9 @@ func (r *Repository) UpdateByID(ID string, update Model) (Model, error) {
 		if user.ID == ID {
 			r.mux.RUnlock()
 			r.mux.Lock()
+			defer r.mux.Unlock()
 			r.players[i] = update
-			r.mux.Unlock()
-			return update, nil
+			return update, r.persist(update)
 		}
 	}
 	r.mux.RUnlock()
@@ -94,7 +108,7 @@ func (r *Repository) FindByID(ID string) (Model, error) {
 	return Model{}, fmt.Errorf("%s user not found", ID)
 }
 
-func (r *Repository) AddPoint(ID string, points int) (Model, error) {
+func (r *Repository) SetScore(ID string, score int) (Model, error) {
 	if r == nil {
 		return Model{}, fmt.Errorf("Repository is not initialised")
 	}
@@ -102,17 +116,84 @@ func (r *Repository) AddPoint(ID string, points int) (Model, error) {
 	defer r.mux.Unlock()
 	for i, p := range r.players {
 		if p.ID == ID {
-			p.Score = p.Score + points
-			r.players[i].Score = p.Score
-			return p, nil
+			p.Score = score
+			r.players[i].Score = score
+			return p, r.persist(r.players[i])
+		}
+	}
+	return Model{}, fmt.Errorf("%s player not found", ID)
+}
+
+func (r *Repository) SetOnlineStatus(ID string, online bool, seenAt time.Time) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i := range r.players {
+		if r.players[i].ID == ID {
+			r.players[i].Online = online
+			if online {
+				r.players[i].LastSeenAt = seenAt
+			}
+			return r.players[i], r.persist(r.players[i])
 		}
 	}
 	return Model{}, fmt.Errorf("%s player not found", ID)
 }
 
+func (r *Repository) SetLastSeen(ID string, seenAt time.Time) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for i := range r.players {
+		if r.players[i].ID == ID {
+			r.players[i].LastSeenAt = seenAt
+			// NOTE: Heartbeats are not persisted one by one
+			// the next persisted change writes the latest value
+			return nil
+		}
+	}
+	return fmt.Errorf("%s player not found", ID)
+}
+
+// Writes a given player into the store if persistence is enabled
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) persist(p Model) error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Update(func(tx storage.ITx) error {
+		return r.storedPlayers.Put(tx, p.ID, p)
+	})
+}
+
 // Constructor to create a new engine repository
 func NewRepository() *Repository {
 	return &Repository{
 		mux: sync.RWMutex{},
 	}
 }
+
+// Constructor to create a repository backed by a store
+// Players stored from a previous run are loaded as offline
+func NewPersistentRepository(store storage.IStore) (*Repository, error) {
+	r := &Repository{
+		mux:           sync.RWMutex{},
+		store:         store,
+		storedPlayers: storage.NewCollection(storeBucket),
+	}
+	if err := r.storedPlayers.Load(store, func(load func(interface{}) error) (string, error) {
+		var p Model
+		err := load(&p)
+		p.Online = false
+		r.players = append(r.players, p)
+		return p.ID, err
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,37 @@
+package player
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/riltech/centurion/core/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	repo, err := NewPersistentRepository(store)
+	assert.Nil(t, err)
+	assert.Nil(t, repo.AddPlayer(Model{
+		ID:     "xxx",
+		Name:   "John",
+		Team:   TeamTypeAttacker,
+		Online: true,
+	}))
+	_, err = repo.SetScore("xxx", 2)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Close())
+
+	store, err = storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	repo, err = NewPersistentRepository(store)
+	assert.Nil(t, err)
+	p, err := repo.FindByID("xxx")
+	assert.Nil(t, err)
+	assert.Equal(t, "John", p.Name)
+	assert.Equal(t, 2, p.Score)
+	assert.False(t, p.Online)
+}
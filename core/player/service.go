@@ -2,6 +2,7 @@ package player
 
 import (
 	"strings"
+	"time"
 )
 
 // Describes a player service interface
@@ -10,12 +11,15 @@ type IService interface {
 	AddPlayer(Model) error
 	// Sets a player's online status
 	SetPlayerOnlineStatus(ID string, online bool) (Model, error)
+	// Records that a message or a heartbeat was received from a player
+	MarkSeen(ID string) error
 	// Finds a player by ID
 	FindByID(ID string) (Model, error)
 	// Checks if a given player is already registered or not
 	IsPlayerExist(*Model) bool
-	// Add given amounts of points to a player
-	AddPoint(ID string, points int) (Model, error)
+	// Sets the score of a player
+	// NOTE: The score is derived from the score ledger
+	SetScore(ID string, score int) (Model, error)
 	// Fetch a given team completely
 	// use Team enums for the string
 	GetTeam(team string) []Model
@@ -48,20 +52,19 @@ func (s Service) IsPlayerExist(p *Model) bool {
 }
 
 func (s Service) SetPlayerOnlineStatus(ID string, online bool) (Model, error) {
-	user, err := s.repository.FindByID(ID)
-	if err != nil {
-		return Model{}, err
-	}
-	user.Online = online
-	return s.repository.UpdateByID(ID, user)
+	return s.repository.SetOnlineStatus(ID, online, time.Now())
+}
+
+func (s Service) MarkSeen(ID string) error {
+	return s.repository.SetLastSeen(ID, time.Now())
 }
 
 func (s Service) FindByID(ID string) (Model, error) {
 	return s.repository.FindByID(ID)
 }
 
-func (s Service) AddPoint(ID string, points int) (Model, error) {
-	return s.repository.AddPoint(ID, points)
+func (s Service) SetScore(ID string, score int) (Model, error) {
+	return s.repository.SetScore(ID, score)
 }
 
 func (s Service) GetTeam(team string) []Model {
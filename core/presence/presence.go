@@ -0,0 +1,93 @@
+package presence
+
+import "time"
+
+// Describes a period of time a player was online
+type Interval struct {
+	// Time the player came online
+	Start time.Time
+	// Time the player went offline
+	// zero while the player is still online
+	End time.Time
+}
+
+// Describes the presence ledger of a single player
+// NOTE: Tracking starts with the first installed challenge
+// of the player, time before that does not count
+type Model struct {
+	// ID of the player
+	PlayerID string
+	// Time the tracking started
+	TrackedSince time.Time
+	// Online intervals of the player in chronological order
+	Intervals []Interval
+}
+
+// Returns true if the player is online according to the ledger
+func (m Model) IsOnline() bool {
+	return len(m.Intervals) > 0 && m.Intervals[len(m.Intervals)-1].End.IsZero()
+}
+
+// Returns the time the player spent online while the game was running
+// (according to given running intervals) until a given time
+func (m Model) GetOnlineTime(running []Interval, until time.Time) time.Duration {
+	online := time.Duration(0)
+	for _, interval := range m.Intervals {
+		online += interval.overlap(running, until)
+	}
+	return online
+}
+
+// Returns the time the game was running (according to given running intervals)
+// since the tracking started until a given time
+func (m Model) GetTrackedTime(running []Interval, until time.Time) time.Duration {
+	return Interval{Start: m.TrackedSince}.overlap(running, until)
+}
+
+// Returns the uptime of the player in percentages
+// while the game was running until a given time
+// values are between 0-100
+func (m Model) GetUptime(running []Interval, until time.Time) int {
+	return percent(m.GetOnlineTime(running, until), m.GetTrackedTime(running, until))
+}
+
+// Returns how long the interval overlaps given intervals until a given time
+// NOTE: Open intervals last until the given time
+func (i Interval) overlap(others []Interval, until time.Time) time.Duration {
+	total := time.Duration(0)
+	for _, other := range others {
+		start, end := i.Start, i.end(until)
+		if other.Start.After(start) {
+			start = other.Start
+		}
+		if otherEnd := other.end(until); otherEnd.Before(end) {
+			end = otherEnd
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// Returns the end of the interval, given time if it is still open
+// or it ended after the given time
+func (i Interval) end(until time.Time) time.Time {
+	if i.End.IsZero() || i.End.After(until) {
+		return until
+	}
+	return i.End
+}
+
+// Calculates the percentage of online compared to tracked time
+// NOTE: A ledger without tracked time counts as 100 percent uptime
+func percent(online time.Duration, tracked time.Duration) int {
+	if tracked <= 0 {
+		return 100
+	}
+	result := int(online * 100 / tracked)
+	if result > 100 {
+		return 100
+	}
+	return result
+}
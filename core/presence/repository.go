@@ -0,0 +1,284 @@
+package presence
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/riltech/centurion/core/storage"
+)
+
+// Bucket of the ledgers of the players in the store
+const storeBucket = "presence"
+
+// Key of the state of the game's presence in the store
+const stateStoreKey = "presence_state"
+
+// Describes a repository for the presence ledger
+type IRepository interface {
+	// Starts tracking a player from a given time
+	// NOTE: It does nothing if the player is already tracked
+	StartTracking(playerID string, online bool, at time.Time) error
+	// Opens or closes the online interval of a tracked player
+	// NOTE: Untracked players are ignored
+	SetOnline(playerID string, online bool, at time.Time) error
+	// Opens or closes the running interval of the game
+	// NOTE: Presence only counts while the game is running
+	SetRunning(running bool, at time.Time) error
+	// Closes every open interval without stopping the tracking
+	// NOTE: Used when the server shuts down
+	Suspend(at time.Time) error
+	// Closes every open interval and stops tracking
+	Stop(at time.Time) error
+	// Returns the time the tracking stopped
+	// zero if the tracking is still running
+	GetStoppedAt() time.Time
+	// Returns the intervals in which the game was running
+	GetRunning() []Interval
+	// Finds the ledger of a given player
+	FindByID(playerID string) (Model, error)
+	// Returns the ledger of every tracked player
+	GetAll() []Model
+}
+
+// Describes the state of the repository
+type storedLedger struct {
+	Players map[string]Model
+	storedState
+}
+
+// Describes the persisted state of the repository besides the players
+type storedState struct {
+	// Intervals in which the game was running in chronological order
+	Running   []Interval
+	StoppedAt time.Time
+	// Time of the last change of the ledger
+	SavedAt time.Time
+}
+
+// Presence repository implementation
+type Repository struct {
+	mux    sync.RWMutex
+	ledger storedLedger
+	// Optional persistence, nil when running in memory only
+	store         storage.IStore
+	storedPlayers *storage.Collection
+}
+
+// Interface check
+var _ IRepository = (*Repository)(nil)
+
+func (r *Repository) StartTracking(playerID string, online bool, at time.Time) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.ledger.Players[playerID]; ok || !r.ledger.StoppedAt.IsZero() {
+		return nil
+	}
+	m := Model{
+		PlayerID:     playerID,
+		TrackedSince: at,
+		Intervals:    []Interval{},
+	}
+	if online {
+		m.Intervals = append(m.Intervals, Interval{Start: at})
+	}
+	r.ledger.Players[playerID] = m
+	return r.persist(playerID)
+}
+
+func (r *Repository) SetOnline(playerID string, online bool, at time.Time) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	m, ok := r.ledger.Players[playerID]
+	if !ok || !r.ledger.StoppedAt.IsZero() || m.IsOnline() == online {
+		return nil
+	}
+	if online {
+		m.Intervals = append(m.Intervals, Interval{Start: at})
+	} else {
+		m.Intervals[len(m.Intervals)-1].End = at
+	}
+	r.ledger.Players[playerID] = m
+	return r.persist(playerID)
+}
+
+func (r *Repository) SetRunning(running bool, at time.Time) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	isRunning := len(r.ledger.Running) > 0 && r.ledger.Running[len(r.ledger.Running)-1].End.IsZero()
+	if !r.ledger.StoppedAt.IsZero() || isRunning == running {
+		return nil
+	}
+	if running {
+		r.ledger.Running = append(r.ledger.Running, Interval{Start: at})
+	} else {
+		r.ledger.Running[len(r.ledger.Running)-1].End = at
+	}
+	return r.persist()
+}
+
+func (r *Repository) Suspend(at time.Time) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.closeIntervals(at)
+	return r.persist(r.getPlayerIDs()...)
+}
+
+func (r *Repository) Stop(at time.Time) error {
+	if r == nil {
+		return fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if !r.ledger.StoppedAt.IsZero() {
+		return nil
+	}
+	r.closeIntervals(at)
+	r.ledger.StoppedAt = at
+	return r.persist(r.getPlayerIDs()...)
+}
+
+func (r *Repository) GetStoppedAt() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.ledger.StoppedAt
+}
+
+func (r *Repository) GetRunning() []Interval {
+	if r == nil {
+		return []Interval{}
+	}
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return append([]Interval{}, r.ledger.Running...)
+}
+
+func (r *Repository) FindByID(playerID string) (Model, error) {
+	if r == nil {
+		return Model{}, fmt.Errorf("Repository is not initialised")
+	}
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	m, ok := r.ledger.Players[playerID]
+	if !ok {
+		return Model{}, fmt.Errorf("%s player is not tracked", playerID)
+	}
+	return copyModel(m), nil
+}
+
+func (r *Repository) GetAll() []Model {
+	if r == nil {
+		return []Model{}
+	}
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	all := make([]Model, 0, len(r.ledger.Players))
+	for _, m := range r.ledger.Players {
+		all = append(all, copyModel(m))
+	}
+	return all
+}
+
+// Closes every open interval of the players and the game at a given time
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) closeIntervals(at time.Time) {
+	for ID, m := range r.ledger.Players {
+		if m.IsOnline() {
+			m.Intervals[len(m.Intervals)-1].End = at
+			r.ledger.Players[ID] = m
+		}
+	}
+	if last := len(r.ledger.Running) - 1; last >= 0 && r.ledger.Running[last].End.IsZero() {
+		r.ledger.Running[last].End = at
+	}
+}
+
+// Returns the ID of every tracked player
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) getPlayerIDs() []string {
+	IDs := make([]string, 0, len(r.ledger.Players))
+	for ID := range r.ledger.Players {
+		IDs = append(IDs, ID)
+	}
+	return IDs
+}
+
+// Writes the state of the ledger and the ledgers of the given players
+// into the store in a single transaction if persistence is enabled
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) persist(playerIDs ...string) error {
+	if r.store == nil {
+		return nil
+	}
+	r.ledger.SavedAt = time.Now()
+	return r.store.Update(func(tx storage.ITx) error {
+		for _, ID := range playerIDs {
+			if err := r.storedPlayers.Put(tx, ID, r.ledger.Players[ID]); err != nil {
+				return err
+			}
+		}
+		return tx.Save(stateStoreKey, r.ledger.storedState)
+	})
+}
+
+// Returns a copy of a given ledger which is safe to use
+// outside of the repository
+func copyModel(m Model) Model {
+	m.Intervals = append([]Interval{}, m.Intervals...)
+	return m
+}
+
+// Constructor to create a new presence repository
+func NewRepository() *Repository {
+	return &Repository{
+		mux: sync.RWMutex{},
+		ledger: storedLedger{
+			Players: make(map[string]Model),
+		},
+	}
+}
+
+// Constructor to create a repository backed by a store
+// NOTE: Every player is offline after a restart and the game is not running
+// until the engine starts, so the intervals left open (after a crash)
+// are closed at the time of the last change
+func NewPersistentRepository(store storage.IStore) (*Repository, error) {
+	r := NewRepository()
+	r.store = store
+	r.storedPlayers = storage.NewCollection(storeBucket)
+	if _, err := store.Load(stateStoreKey, &r.ledger.storedState); err != nil {
+		return nil, err
+	}
+	if err := r.storedPlayers.Load(store, func(load func(interface{}) error) (string, error) {
+		var m Model
+		err := load(&m)
+		r.ledger.Players[m.PlayerID] = m
+		return m.PlayerID, err
+	}); err != nil {
+		return nil, err
+	}
+	closedAt := r.ledger.SavedAt
+	if closedAt.IsZero() {
+		closedAt = time.Now()
+	}
+	r.closeIntervals(closedAt)
+	return r, nil
+}
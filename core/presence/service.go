@@ -0,0 +1,107 @@
+package presence
+
+import (
+	"time"
+)
+
+// Describes a presence service interface
+type IService interface {
+	// Starts tracking the presence of a given player
+	// NOTE: Calling it again for the same player does nothing
+	StartTracking(playerID string, online bool) error
+	// Records that a given player came online or went offline
+	SetOnlineStatus(playerID string, online bool) error
+	// Records that the game started or stopped running
+	// NOTE: Only the time the game is running counts in the uptimes
+	SetRunning(running bool) error
+	// Closes every open interval, used when the server shuts down
+	Suspend() error
+	// Stops tracking every player, used when the game finishes
+	Stop() error
+	// Returns the uptime of a given player in percentages
+	// values are between 0-100
+	GetUptime(playerID string) (int, error)
+	// Returns the uptime of every tracked player in percentages by player ID
+	GetUptimes() map[string]int
+	// Returns the overall uptime of the tracked players in percentages
+	// returns false if nobody is tracked yet
+	GetTeamUptime() (int, bool)
+}
+
+// Service implementation
+type Service struct {
+	repository IRepository
+	// Returns the current time
+	now func() time.Time
+}
+
+// Interface check
+var _ IService = (*Service)(nil)
+
+func (s Service) StartTracking(playerID string, online bool) error {
+	return s.repository.StartTracking(playerID, online, s.now())
+}
+
+func (s Service) SetOnlineStatus(playerID string, online bool) error {
+	return s.repository.SetOnline(playerID, online, s.now())
+}
+
+func (s Service) SetRunning(running bool) error {
+	return s.repository.SetRunning(running, s.now())
+}
+
+func (s Service) Suspend() error {
+	return s.repository.Suspend(s.now())
+}
+
+func (s Service) Stop() error {
+	return s.repository.Stop(s.now())
+}
+
+func (s Service) GetUptime(playerID string) (int, error) {
+	m, err := s.repository.FindByID(playerID)
+	if err != nil {
+		return 0, err
+	}
+	return m.GetUptime(s.repository.GetRunning(), s.until()), nil
+}
+
+func (s Service) GetUptimes() map[string]int {
+	until, running := s.until(), s.repository.GetRunning()
+	uptimes := map[string]int{}
+	for _, m := range s.repository.GetAll() {
+		uptimes[m.PlayerID] = m.GetUptime(running, until)
+	}
+	return uptimes
+}
+
+func (s Service) GetTeamUptime() (int, bool) {
+	until, running := s.until(), s.repository.GetRunning()
+	all := s.repository.GetAll()
+	if len(all) == 0 {
+		return 0, false
+	}
+	online, tracked := time.Duration(0), time.Duration(0)
+	for _, m := range all {
+		online += m.GetOnlineTime(running, until)
+		tracked += m.GetTrackedTime(running, until)
+	}
+	return percent(online, tracked), true
+}
+
+// Returns the end of the measured period
+// which is the time the tracking stopped or now
+func (s Service) until() time.Time {
+	if stoppedAt := s.repository.GetStoppedAt(); !stoppedAt.IsZero() {
+		return stoppedAt
+	}
+	return s.now()
+}
+
+// Constructor for the presence service
+func NewService(repository IRepository) IService {
+	return &Service{
+		repository: repository,
+		now:        time.Now,
+	}
+}
@@ -0,0 +1,97 @@
+package presence
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/riltech/centurion/core/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUptime(t *testing.T) {
+	start := time.Now()
+	now := start
+	service := &Service{
+		repository: NewRepository(),
+		now:        func() time.Time { return now },
+	}
+	_, ok := service.GetTeamUptime()
+	assert.False(t, ok)
+	assert.Nil(t, service.SetRunning(true))
+
+	// Status changes before the first installed challenge are ignored
+	assert.Nil(t, service.SetOnlineStatus("def", true))
+	_, err := service.GetUptime("def")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, service.StartTracking("def", true))
+	assert.Nil(t, service.StartTracking("other", false))
+	now = start.Add(30 * time.Minute)
+	assert.Nil(t, service.SetOnlineStatus("def", false))
+	// Repeated status changes do not open new intervals
+	assert.Nil(t, service.SetOnlineStatus("def", false))
+	assert.Nil(t, service.SetOnlineStatus("other", true))
+	now = start.Add(time.Hour)
+
+	uptime, err := service.GetUptime("def")
+	assert.Nil(t, err)
+	assert.Equal(t, 50, uptime)
+	assert.Equal(t, map[string]int{"def": 50, "other": 50}, service.GetUptimes())
+	teamUptime, ok := service.GetTeamUptime()
+	assert.True(t, ok)
+	assert.Equal(t, 50, teamUptime)
+
+	// Time while the game is not running does not count
+	assert.Nil(t, service.SetRunning(false))
+	now = start.Add(90 * time.Minute)
+	assert.Nil(t, service.SetRunning(true))
+	now = start.Add(2 * time.Hour)
+	uptime, err = service.GetUptime("def")
+	assert.Nil(t, err)
+	assert.Equal(t, 33, uptime)
+	uptime, err = service.GetUptime("other")
+	assert.Nil(t, err)
+	assert.Equal(t, 66, uptime)
+
+	// Time after the game finished does not count
+	assert.Nil(t, service.Stop())
+	now = start.Add(3 * time.Hour)
+	assert.Nil(t, service.SetOnlineStatus("def", true))
+	assert.Nil(t, service.SetRunning(true))
+	uptime, err = service.GetUptime("other")
+	assert.Nil(t, err)
+	assert.Equal(t, 66, uptime)
+}
+
+func TestPersistentRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	repo, err := NewPersistentRepository(store)
+	assert.Nil(t, err)
+	start := time.Now().Add(-time.Hour)
+	assert.Nil(t, repo.SetRunning(true, start))
+	assert.Nil(t, repo.StartTracking("def", true, start))
+	assert.Nil(t, store.Close())
+	// The server is down for a while
+	time.Sleep(10 * time.Millisecond)
+
+	store, err = storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	repo, err = NewPersistentRepository(store)
+	assert.Nil(t, err)
+	m, err := repo.FindByID("def")
+	assert.Nil(t, err)
+	assert.True(t, start.Equal(m.TrackedSince))
+	// Players are offline after a restart
+	assert.False(t, m.IsOnline())
+	assert.Len(t, m.Intervals, 1)
+	// Intervals left open are closed at the time of the last change
+	running := repo.GetRunning()
+	assert.Len(t, running, 1)
+	assert.Equal(t, m.Intervals[0].End, running[0].End)
+	assert.True(t, running[0].End.Before(time.Now().Add(-5*time.Millisecond)))
+	assert.Equal(t, 100, m.GetUptime(running, time.Now()))
+}
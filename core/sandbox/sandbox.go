@@ -0,0 +1,320 @@
+package sandbox
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Functions and memory a module has to export
+const ExportMemory = "memory"
+const ExportAlloc = "alloc"
+const ExportGenerateHints = "generate_hints"
+const ExportEvaluate = "evaluate"
+
+// Maximum size of a module in bytes
+const MaxModuleSize = 4 << 20
+
+// Maximum size of the hints a module can generate in bytes
+const maxOutputSize = 64 << 10
+
+// Maximum number of compiled modules kept in the cache
+const maxCompiledModules = 32
+
+// Describes a sandbox running the WebAssembly modules of hosted challenges
+// The ABI of a module is the following (every function is called on a fresh instance)
+//   - generate_hints(seed i64) i64 returns the pointer (high 32 bits) and the length (low 32 bits)
+//     of a JSON encoded hints array in the exported memory
+//   - alloc(size i32) i32 returns a pointer to a given number of free bytes
+//   - evaluate(ptr i32, len i32) i32 receives {"hints": [...], "solutions": [...]} JSON encoded
+//     at the allocated pointer and returns 1 if the solutions are valid, 0 otherwise
+type IService interface {
+	// Checks if a given module can serve a challenge
+	Validate(module []byte) error
+	// Generates hints for a new attack with a given module
+	GenerateHints(module []byte) ([]interface{}, error)
+	// Evaluates given solutions to given hints with a given module
+	Evaluate(module []byte, hints []interface{}, solutions []interface{}) (bool, error)
+	// Releases every resource of the sandbox
+	Close() error
+}
+
+// Describes the resources a module can use in a single call
+type Limits struct {
+	// Time a single call can run for
+	Timeout time.Duration
+	// Memory a module can use in 64KiB pages
+	MemoryPages uint32
+}
+
+type Service struct {
+	runtime wazero.Runtime
+	limits  Limits
+	random  *rand.Rand
+	// Compiled modules by their checksum
+	compiled map[[sha256.Size]byte]*list.Element
+	// Cached compiled modules, the most recently used first
+	recent *list.List
+	mux    sync.Mutex
+}
+
+// Describes a compiled module of the cache
+type compiledModule struct {
+	checksum [sha256.Size]byte
+	module   wazero.CompiledModule
+	// Number of calls using the module
+	users int
+	// Removed from the cache, closed when the last user releases it
+	evicted bool
+}
+
+// Interface check
+var _ IService = (*Service)(nil)
+
+// Describes the input of the evaluate function
+type evaluationInput struct {
+	Hints     []interface{} `json:"hints"`
+	Solutions []interface{} `json:"solutions"`
+}
+
+// Returns a sandbox with given limits
+// Seeds of the generated hints are drawn from a source seeded with a given seed
+// NOTE: Zero seed picks a random seed
+func NewService(limits Limits, seed int64) IService {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	ctx := context.Background()
+	// The interpreter runs on every platform and modules can be interrupted
+	// when they run out of time
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter().
+		WithMemoryLimitPages(limits.MemoryPages).
+		WithCloseOnContextDone(true))
+	// Toolchains targeting WASI need it even for pure functions,
+	// modules get no file system, environment or real clock
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+	return &Service{
+		runtime:  runtime,
+		limits:   limits,
+		random:   rand.New(rand.NewSource(seed)),
+		compiled: map[[sha256.Size]byte]*list.Element{},
+		recent:   list.New(),
+	}
+}
+
+func (s *Service) Validate(module []byte) error {
+	compiled, err := s.acquire(module)
+	if err != nil {
+		return err
+	}
+	defer s.release(compiled)
+	return s.validate(compiled)
+}
+
+func (s *Service) GenerateHints(module []byte) ([]interface{}, error) {
+	s.mux.Lock()
+	seed := s.random.Int63()
+	s.mux.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), s.limits.Timeout)
+	defer cancel()
+	instance, err := s.instantiate(ctx, module, seed)
+	if err != nil {
+		return nil, err
+	}
+	defer instance.Close(ctx)
+	results, err := s.call(ctx, instance, ExportGenerateHints, uint64(seed))
+	if err != nil {
+		return nil, err
+	}
+	ptr, size := uint32(results[0]>>32), uint32(results[0])
+	if size > maxOutputSize {
+		return nil, fmt.Errorf("Hints cannot be longer than %d bytes", maxOutputSize)
+	}
+	output, ok := instance.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("Hints are out of the memory of the module")
+	}
+	hints := []interface{}{}
+	if err = json.Unmarshal(output, &hints); err != nil {
+		return nil, fmt.Errorf("Hints have to be a JSON array")
+	}
+	return hints, nil
+}
+
+func (s *Service) Evaluate(module []byte, hints []interface{}, solutions []interface{}) (bool, error) {
+	input, err := json.Marshal(evaluationInput{hints, solutions})
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.limits.Timeout)
+	defer cancel()
+	// Evaluations have to be deterministic, they always get the same random source
+	instance, err := s.instantiate(ctx, module, 0)
+	if err != nil {
+		return false, err
+	}
+	defer instance.Close(ctx)
+	results, err := s.call(ctx, instance, ExportAlloc, uint64(len(input)))
+	if err != nil {
+		return false, err
+	}
+	ptr := uint32(results[0])
+	if ok := instance.Memory().Write(ptr, input); !ok {
+		return false, fmt.Errorf("Allocated memory is out of the memory of the module")
+	}
+	if results, err = s.call(ctx, instance, ExportEvaluate, uint64(ptr), uint64(len(input))); err != nil {
+		return false, err
+	}
+	return uint32(results[0]) == 1, nil
+}
+
+func (s *Service) Close() error {
+	return s.runtime.Close(context.Background())
+}
+
+// Checks if a given compiled module can serve a challenge
+// NOTE: Invalid modules are removed from the cache
+func (s *Service) validate(compiled *compiledModule) error {
+	err := validateExports(compiled.module)
+	if err != nil {
+		s.mux.Lock()
+		s.evict(compiled)
+		s.mux.Unlock()
+	}
+	return err
+}
+
+// Checks if a given compiled module exports everything the ABI needs
+func validateExports(compiled wazero.CompiledModule) error {
+	if _, ok := compiled.ExportedMemories()[ExportMemory]; !ok {
+		return fmt.Errorf("Module has to export its memory as %s", ExportMemory)
+	}
+	signatures := map[string][2][]api.ValueType{
+		ExportAlloc:         {{api.ValueTypeI32}, {api.ValueTypeI32}},
+		ExportGenerateHints: {{api.ValueTypeI64}, {api.ValueTypeI64}},
+		ExportEvaluate:      {{api.ValueTypeI32, api.ValueTypeI32}, {api.ValueTypeI32}},
+	}
+	exported := compiled.ExportedFunctions()
+	for name, signature := range signatures {
+		definition, ok := exported[name]
+		if !ok {
+			return fmt.Errorf("Module has to export the %s function", name)
+		}
+		if !isSameTypes(definition.ParamTypes(), signature[0]) || !isSameTypes(definition.ResultTypes(), signature[1]) {
+			return fmt.Errorf("Signature of the %s function is invalid", name)
+		}
+	}
+	return nil
+}
+
+// Compiles a given module or returns it from the cache
+// NOTE: Every acquired module has to be released
+func (s *Service) acquire(module []byte) (*compiledModule, error) {
+	if len(module) > MaxModuleSize {
+		return nil, fmt.Errorf("Module cannot be larger than %d bytes", MaxModuleSize)
+	}
+	checksum := sha256.Sum256(module)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if element, ok := s.compiled[checksum]; ok {
+		s.recent.MoveToFront(element)
+		compiled := element.Value.(*compiledModule)
+		compiled.users++
+		return compiled, nil
+	}
+	compiledCode, err := s.runtime.CompileModule(context.Background(), module)
+	if err != nil {
+		return nil, fmt.Errorf("Module is invalid: %w", err)
+	}
+	compiled := &compiledModule{checksum: checksum, module: compiledCode, users: 1}
+	s.compiled[checksum] = s.recent.PushFront(compiled)
+	// The least recently used modules are evicted to keep the cache bounded
+	for s.recent.Len() > maxCompiledModules {
+		s.evict(s.recent.Back().Value.(*compiledModule))
+	}
+	return compiled, nil
+}
+
+// Releases a given acquired module
+func (s *Service) release(compiled *compiledModule) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	compiled.users--
+	s.closeEvicted(compiled)
+}
+
+// Removes a given module from the cache
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (s *Service) evict(compiled *compiledModule) {
+	if compiled.evicted {
+		return
+	}
+	compiled.evicted = true
+	s.recent.Remove(s.compiled[compiled.checksum])
+	delete(s.compiled, compiled.checksum)
+	s.closeEvicted(compiled)
+}
+
+// Closes a given module if it is evicted and nothing uses it anymore
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (s *Service) closeEvicted(compiled *compiledModule) {
+	if !compiled.evicted || compiled.users > 0 {
+		return
+	}
+	// Closing only releases memory, there is nothing to do if it fails
+	compiled.module.Close(context.Background())
+}
+
+// Returns a fresh instance of a given module
+// NOTE: Instances are anonymous so the same module can run concurrently
+func (s *Service) instantiate(ctx context.Context, module []byte, seed int64) (api.Module, error) {
+	compiled, err := s.acquire(module)
+	if err != nil {
+		return nil, err
+	}
+	defer s.release(compiled)
+	if err := s.validate(compiled); err != nil {
+		return nil, err
+	}
+	return s.runtime.InstantiateModule(ctx, compiled.module, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithRandSource(rand.New(rand.NewSource(seed))))
+}
+
+// Calls an exported function of a given instance
+func (s *Service) call(ctx context.Context, instance api.Module, name string, params ...uint64) ([]uint64, error) {
+	results, err := instance.ExportedFunction(name).Call(ctx, params...)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("Module did not finish %s in %s", name, s.limits.Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Module failed in %s: %w", name, err)
+	}
+	return results, nil
+}
+
+// Returns true if given value types are the same
+func isSameTypes(a []api.ValueType, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
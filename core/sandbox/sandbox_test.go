@@ -0,0 +1,81 @@
+package sandbox
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestService(t *testing.T) IService {
+	service := NewService(Limits{Timeout: 200 * time.Millisecond, MemoryPages: 256}, 1)
+	t.Cleanup(func() {
+		assert.Nil(t, service.Close())
+	})
+	return service
+}
+
+func readModule(t *testing.T, name string) []byte {
+	module, err := os.ReadFile("testdata/" + name)
+	assert.Nil(t, err)
+	return module
+}
+
+func TestSandbox(t *testing.T) {
+	service := newTestService(t)
+	module := readModule(t, "reverse.wasm")
+	assert.Nil(t, service.Validate(module))
+
+	hints, err := service.GenerateHints(module)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"abc"}, hints)
+
+	isValid, err := service.Evaluate(module, hints, []interface{}{"cba"})
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+	isValid, err = service.Evaluate(module, hints, []interface{}{"abc"})
+	assert.Nil(t, err)
+	assert.False(t, isValid)
+}
+
+func TestSandboxLimits(t *testing.T) {
+	service := newTestService(t)
+	assert.NotNil(t, service.Validate([]byte("not a module")))
+	assert.NotNil(t, service.Validate(make([]byte, MaxModuleSize+1)))
+	// Memory above the limit is rejected
+	assert.NotNil(t, service.Validate(readModule(t, "greedy.wasm")))
+
+	// Modules running out of time are interrupted
+	module := readModule(t, "spin.wasm")
+	assert.Nil(t, service.Validate(module))
+	start := time.Now()
+	_, err := service.GenerateHints(module)
+	assert.NotNil(t, err)
+	_, err = service.Evaluate(module, []interface{}{"abc"}, []interface{}{"cba"})
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestCompiledModuleCache(t *testing.T) {
+	service := newTestService(t).(*Service)
+	module := readModule(t, "reverse.wasm")
+	// Custom sections change the checksum without changing the module
+	for i := 0; i < maxCompiledModules+5; i++ {
+		variant := append(append([]byte{}, module...), 0x00, 0x03, 0x01, 'v', byte(i))
+		assert.Nil(t, service.Validate(variant))
+	}
+	assert.Len(t, service.compiled, maxCompiledModules)
+	assert.Equal(t, maxCompiledModules, service.recent.Len())
+
+	// Evicted modules can still be used
+	hints, err := service.GenerateHints(append(append([]byte{}, module...), 0x00, 0x03, 0x01, 'v', 0))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"abc"}, hints)
+
+	// Modules failing the validation are not kept
+	service = newTestService(t).(*Service)
+	assert.NotNil(t, service.Validate([]byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}))
+	assert.Len(t, service.compiled, 0)
+	assert.Equal(t, 0, service.recent.Len())
+}
@@ -0,0 +1,25 @@
+;; Source of greedy.wasm
+;; Same as reverse.wat but it asks for 512 pages (32MiB) of memory
+(module
+  (memory (export "memory") 512)
+  (data (i32.const 16) "[\"abc\"]")
+  (data (i32.const 64) "{\"hints\":[\"abc\"],\"solutions\":[\"cba\"]}")
+  (func (export "alloc") (param i32) (result i32)
+    i32.const 1024)
+  (func (export "generate_hints") (param i64) (result i64)
+    ;; Pointer 16 in the high, length 7 in the low 32 bits
+    i64.const 68719476743)
+  (func (export "evaluate") (param $ptr i32) (param $len i32) (result i32)
+    (local $i i32)
+    (if (i32.ne (local.get $len) (i32.const 37))
+      (then (return (i32.const 0))))
+    (block $done
+      (loop $next
+        (br_if $done (i32.ge_u (local.get $i) (local.get $len)))
+        (if (i32.ne
+              (i32.load8_u (i32.add (local.get $ptr) (local.get $i)))
+              (i32.load8_u (i32.add (i32.const 64) (local.get $i))))
+          (then (return (i32.const 0))))
+        (local.set $i (i32.add (local.get $i) (i32.const 1)))
+        (br $next)))
+    i32.const 1))
@@ -0,0 +1,14 @@
+;; Source of spin.wasm
+;; Never returns from generate_hints or evaluate
+(module
+  (memory (export "memory") 1)
+  (data (i32.const 16) "[\"abc\"]")
+  (data (i32.const 64) "{\"hints\":[\"abc\"],\"solutions\":[\"cba\"]}")
+  (func (export "alloc") (param i32) (result i32)
+    i32.const 1024)
+  (func (export "generate_hints") (param i64) (result i64)
+    (loop $forever (br $forever))
+    i64.const 0)
+  (func (export "evaluate") (param i32) (param i32) (result i32)
+    (loop $forever (br $forever))
+    i32.const 0))
@@ -1,25 +1,33 @@
 package scoreboard
 
 import (
+	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
-	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/storage"
 )
 
-// Describes a repository for the engine
+// Bucket of the score ledger in the store
+const storeBucket = "score_ledger"
+
+// Describes a repository for the score ledger
 type IRepository interface {
-	// Returns the score board for each team
-	GetBoards() (attacker Model, defender Model)
-	// Adds a given point to a team
-	// Use enums from player package to [team]
-	AddPoint(team string, point int)
+	// Appends a new entry to the ledger
+	// NOTE: The time of the entry is set by the repository
+	Append(entry Entry) (Entry, error)
+	// Returns every entry of the ledger in chronological order
+	GetEntries() []Entry
 }
 
-// Combat repository implementation
+// Score ledger repository implementation
 type Repository struct {
-	mux      sync.RWMutex
-	attacker Model
-	defender Model
+	mux     sync.RWMutex
+	entries []Entry
+	// Optional persistence, nil when running in memory only
+	store         storage.IStore
+	storedEntries *storage.Collection
 }
 
 // Interface check
@@ -27,39 +35,59 @@ var _ IRepository = (*Repository)(nil)
 
 func NewRepository() IRepository {
 	return &Repository{
-		mux: sync.RWMutex{},
-		attacker: Model{
-			Team:         player.TeamTypeAttacker,
-			OverallScore: 0,
-		},
-		defender: Model{
-			Team:         player.TeamTypeDefender,
-			OverallScore: 0,
-		},
+		mux:     sync.RWMutex{},
+		entries: []Entry{},
 	}
 }
 
-func (r *Repository) GetBoards() (Model, Model) {
-	if r == nil {
-		return Model{}, Model{}
+// Constructor to create a repository backed by a store
+func NewPersistentRepository(store storage.IStore) (IRepository, error) {
+	r := NewRepository().(*Repository)
+	r.store = store
+	r.storedEntries = storage.NewCollection(storeBucket)
+	if err := r.storedEntries.Load(store, func(load func(interface{}) error) (string, error) {
+		var entry Entry
+		err := load(&entry)
+		r.entries = append(r.entries, entry)
+		// Entries are identified by their position in the ledger
+		return strconv.Itoa(len(r.entries) - 1), err
+	}); err != nil {
+		return nil, err
 	}
-	r.mux.RLock()
-	defer r.mux.RUnlock()
-	return r.attacker, r.defender
+	return r, nil
+}
+
+// Writes the last entry of the ledger into the store if persistence is enabled
+// NOTE: This function is not thread safe
+// Using this function requires the mux already being locked
+func (r *Repository) persist() error {
+	if r.store == nil {
+		return nil
+	}
+	last := len(r.entries) - 1
+	return r.store.Update(func(tx storage.ITx) error {
+		return r.storedEntries.Put(tx, strconv.Itoa(last), r.entries[last])
+	})
 }
 
-func (r *Repository) AddPoint(team string, points int) {
+func (r *Repository) Append(entry Entry) (Entry, error) {
 	if r == nil {
-		return
+		return Entry{}, fmt.Errorf("Repository is not initialised")
 	}
 	r.mux.Lock()
 	defer r.mux.Unlock()
-	if team == player.TeamTypeAttacker {
-		r.attacker.OverallScore = r.attacker.OverallScore + points
-		return
-	}
-	if team == player.TeamTypeDefender {
-		r.defender.OverallScore = r.defender.OverallScore + points
-		return
+	entry.At = time.Now()
+	r.entries = append(r.entries, entry)
+	return entry, r.persist()
+}
+
+func (r *Repository) GetEntries() []Entry {
+	if r == nil {
+		return []Entry{}
 	}
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
 }
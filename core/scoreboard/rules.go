@@ -0,0 +1,243 @@
+package scoreboard
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/riltech/centurion/core/challenge"
+)
+
+// Decay modes of the first solution reward
+const (
+	// Reward does not decay
+	DecayModeNone = ""
+	// Reward decays with the age of the challenge
+	DecayModeAge = "age"
+	// Reward decays with the number of prior solvers
+	DecayModeSolvers = "solvers"
+)
+
+// Default scoring rules of the game
+//
+//go:embed rules.json
+var defaultRules []byte
+
+// Describes how many points are given for what
+// NOTE: Zero points disable a given award
+type Rules struct {
+	Attacker AttackerRules `json:"attacker"`
+	Defender DefenderRules `json:"defender"`
+	// Multipliers of the challenge related awards by difficulty
+	// NOTE: Challenges without a known difficulty are not scaled
+	Difficulty map[string]int `json:"difficulty"`
+}
+
+// Describes the awards of the attackers
+type AttackerRules struct {
+	// Points for the first solution of a challenge
+	FirstSolution int `json:"firstSolution"`
+	// Decay of the first solution reward
+	Decay DecayRule `json:"decay"`
+	// Bonus for the first attacker solving a challenge
+	FirstBlood int `json:"firstBlood"`
+	// Points for every Nth unique solution
+	UniqueSolutionMilestone Milestone `json:"uniqueSolutionMilestone"`
+	// Points for an attack which the defender failed to defend
+	DefenderFailed int `json:"defenderFailed"`
+	// Team award if the attackers solved enough of the challenges
+	TeamSuccess Threshold `json:"teamSuccess"`
+	// Team award for every challenge solved by every attacker
+	FullCompletion int `json:"fullCompletion"`
+}
+
+// Describes the awards of the defenders
+type DefenderRules struct {
+	// Points for the first installed challenge
+	FirstChallenge int `json:"firstChallenge"`
+	// Points for every successful defense flow
+	DefenseFlow int `json:"defenseFlow"`
+	// Points for every challenge solved by at least one
+	// but not more than a given percent of the attackers
+	ContestedChallenge ContestedRule `json:"contestedChallenge"`
+	// Points taken for every failed determinism audit
+	AuditPenalty int `json:"auditPenalty"`
+	// Team award based on the uptime of the defenders
+	// NOTE: The tier with the highest reached percent is used
+	Uptime []Threshold `json:"uptime"`
+}
+
+// Describes points given for every Nth occasion
+type Milestone struct {
+	// Zero disables the milestone
+	Every  int `json:"every"`
+	Points int `json:"points"`
+}
+
+// Describes points given when a percentage is reached
+type Threshold struct {
+	// Values are between 0-100
+	MinPercent int `json:"minPercent"`
+	Points     int `json:"points"`
+}
+
+// Describes the award of the contested challenges
+type ContestedRule struct {
+	// Values are between 0-100
+	MaxSolverPercent int `json:"maxSolverPercent"`
+	Points           int `json:"points"`
+}
+
+// Describes how the first solution reward decreases
+// NOTE: The reward is decreased by Step for every Every
+// minutes of challenge age or for every Every prior solvers
+type DecayRule struct {
+	// Use the decay mode enums
+	Mode  string `json:"mode"`
+	Every int    `json:"every"`
+	Step  int    `json:"step"`
+	// Reward never goes below this value
+	Min int `json:"min"`
+}
+
+// Returns the decayed value of given points
+func (d DecayRule) Apply(points int, age time.Duration, priorSolvers int) int {
+	var steps int
+	switch d.Mode {
+	case DecayModeAge:
+		steps = int(age/time.Minute) / d.Every
+	case DecayModeSolvers:
+		steps = priorSolvers / d.Every
+	default:
+		return points
+	}
+	decayed := points - steps*d.Step
+	if decayed < d.Min {
+		return d.Min
+	}
+	return decayed
+}
+
+// Returns the points of the first solution of a challenge
+// created a given time ago and solved by a given number of attackers before
+func (r AttackerRules) GetFirstSolutionPoints(age time.Duration, priorSolvers int) int {
+	return r.Decay.Apply(r.FirstSolution, age, priorSolvers)
+}
+
+// Returns true if a given count reaches the milestone
+func (m Milestone) IsReached(count int) bool {
+	return m.Every > 0 && count > 0 && count%m.Every == 0
+}
+
+// Returns true if a given percent reaches the threshold
+func (t Threshold) IsReached(percent int) bool {
+	return percent >= t.MinPercent
+}
+
+// Returns true if a challenge with a given number of solvers is contested
+func (c ContestedRule) IsContested(solvers int, numberOfAttackers int) bool {
+	return solvers >= 1 && solvers*100 <= c.MaxSolverPercent*numberOfAttackers
+}
+
+// Returns the points of the highest uptime tier reached
+// returns 0 if no tier is reached
+func (r DefenderRules) GetUptimePoints(uptime int) int {
+	best := -1
+	points := 0
+	for _, tier := range r.Uptime {
+		if tier.IsReached(uptime) && tier.MinPercent > best {
+			best = tier.MinPercent
+			points = tier.Points
+		}
+	}
+	return points
+}
+
+// Scales given points of a challenge related award by a given difficulty
+func (r Rules) Scale(points int, difficulty string) int {
+	if multiplier, ok := r.Difficulty[difficulty]; ok {
+		return points * multiplier
+	}
+	return points
+}
+
+// Validates the rules, returns an error if any of the values is invalid
+func (r Rules) Validate() error {
+	if r.Attacker.UniqueSolutionMilestone.Every < 0 {
+		return fmt.Errorf("Unique solution milestone cannot be negative")
+	}
+	if r.Defender.AuditPenalty < 0 {
+		return fmt.Errorf("Audit penalty cannot be negative")
+	}
+	decay := r.Attacker.Decay
+	switch decay.Mode {
+	case DecayModeNone:
+	case DecayModeAge, DecayModeSolvers:
+		if decay.Every < 1 || decay.Step < 0 {
+			return fmt.Errorf("Decay needs a positive interval and a non-negative step")
+		}
+	default:
+		return fmt.Errorf("Unknown decay mode %s", decay.Mode)
+	}
+	percents := map[string]int{
+		"team success":        r.Attacker.TeamSuccess.MinPercent,
+		"contested challenge": r.Defender.ContestedChallenge.MaxSolverPercent,
+	}
+	for difficulty, multiplier := range r.Difficulty {
+		if !challenge.IsValidDifficulty(difficulty) {
+			return fmt.Errorf("Unknown difficulty %s", difficulty)
+		}
+		if multiplier < 0 {
+			return fmt.Errorf("Multiplier of %s difficulty cannot be negative", difficulty)
+		}
+	}
+	for i, tier := range r.Defender.Uptime {
+		percents[fmt.Sprintf("uptime tier %d", i+1)] = tier.MinPercent
+	}
+	for name, percent := range percents {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("Percent of %s has to be between 0-100, got %d", name, percent)
+		}
+	}
+	return nil
+}
+
+// Returns the default scoring rules
+func DefaultRules() Rules {
+	rules, err := parseRules(defaultRules)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// Loads the scoring rules from a JSON file
+// NOTE: The default rules are used if the path is empty
+func LoadRules(path string) (Rules, error) {
+	if path == "" {
+		return DefaultRules(), nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+	return parseRules(content)
+}
+
+// Parses and validates the scoring rules
+// NOTE: Unknown fields are rejected to catch typos
+func parseRules(content []byte) (Rules, error) {
+	var rules Rules
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return Rules{}, fmt.Errorf("Invalid scoring rules: %s", err.Error())
+	}
+	if err := rules.Validate(); err != nil {
+		return Rules{}, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,93 @@
+package scoreboard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRules(t *testing.T) {
+	rules := DefaultRules()
+	assert.Equal(t, 1, rules.Attacker.FirstSolution)
+	assert.True(t, rules.Attacker.UniqueSolutionMilestone.IsReached(10))
+	assert.False(t, rules.Attacker.UniqueSolutionMilestone.IsReached(4))
+	assert.True(t, rules.Attacker.TeamSuccess.IsReached(80))
+	assert.False(t, rules.Attacker.TeamSuccess.IsReached(79))
+	assert.True(t, rules.Defender.ContestedChallenge.IsContested(2, 4))
+	assert.False(t, rules.Defender.ContestedChallenge.IsContested(3, 4))
+	assert.False(t, rules.Defender.ContestedChallenge.IsContested(0, 4))
+
+	// Uptime ladder of the game
+	for uptime, points := range map[int]int{100: 10, 97: 10, 96: 9, 89: 8, 80: 5, 65: 2, 64: 1, 0: 1} {
+		assert.Equal(t, points, rules.Defender.GetUptimePoints(uptime), "uptime %d", uptime)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	rules, err := LoadRules("")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultRules(), rules)
+
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "rules.json")
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	rules, err = LoadRules(write(`{"attacker": {"firstSolution": 3}, "defender": {"uptime": [{"minPercent": 50, "points": 4}]}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, rules.Attacker.FirstSolution)
+	assert.Equal(t, 4, rules.Defender.GetUptimePoints(60))
+	assert.Equal(t, 0, rules.Defender.GetUptimePoints(40))
+	// Omitted milestone is disabled
+	assert.False(t, rules.Attacker.UniqueSolutionMilestone.IsReached(5))
+
+	_, err = LoadRules(write(`{"attacker": {"firstSolutoin": 3}}`))
+	assert.NotNil(t, err)
+	_, err = LoadRules(write(`{"attacker": {"teamSuccess": {"minPercent": 120}}}`))
+	assert.NotNil(t, err)
+	_, err = LoadRules(write(`{"defender": {"auditPenalty": -1}}`))
+	assert.NotNil(t, err)
+	_, err = LoadRules(filepath.Join(dir, "missing.json"))
+	assert.NotNil(t, err)
+}
+
+func TestDecay(t *testing.T) {
+	rules := AttackerRules{FirstSolution: 5}
+	// Without decay every solution is worth the same
+	assert.Equal(t, 5, rules.GetFirstSolutionPoints(time.Hour, 10))
+
+	rules.Decay = DecayRule{Mode: DecayModeAge, Every: 30, Step: 1, Min: 2}
+	assert.Equal(t, 5, rules.GetFirstSolutionPoints(29*time.Minute, 10))
+	assert.Equal(t, 4, rules.GetFirstSolutionPoints(30*time.Minute, 10))
+	assert.Equal(t, 2, rules.GetFirstSolutionPoints(10*time.Hour, 0))
+
+	rules.Decay = DecayRule{Mode: DecayModeSolvers, Every: 2, Step: 2}
+	assert.Equal(t, 5, rules.GetFirstSolutionPoints(time.Hour, 1))
+	assert.Equal(t, 1, rules.GetFirstSolutionPoints(time.Hour, 4))
+	assert.Equal(t, 0, rules.GetFirstSolutionPoints(time.Hour, 6))
+
+	assert.NotNil(t, Rules{Attacker: AttackerRules{Decay: DecayRule{Mode: "random"}}}.Validate())
+	assert.NotNil(t, Rules{Attacker: AttackerRules{Decay: DecayRule{Mode: DecayModeAge}}}.Validate())
+}
+
+func TestDifficulty(t *testing.T) {
+	rules := DefaultRules()
+	// Every tier is worth the same by default
+	assert.Equal(t, 2, rules.Scale(2, "hard"))
+
+	rules.Difficulty = map[string]int{"easy": 1, "hard": 3}
+	assert.Equal(t, 6, rules.Scale(2, "hard"))
+	assert.Equal(t, 2, rules.Scale(2, "easy"))
+	// Unknown tiers are not scaled
+	assert.Equal(t, 2, rules.Scale(2, "medium"))
+	assert.Equal(t, 2, rules.Scale(2, ""))
+
+	rules.Difficulty = map[string]int{"impossible": 5}
+	assert.NotNil(t, rules.Validate())
+	rules.Difficulty = map[string]int{"hard": -1}
+	assert.NotNil(t, rules.Validate())
+}
@@ -1,5 +1,41 @@
 package scoreboard
 
+import "time"
+
+// Reason codes of the score ledger entries
+const (
+	// Defender installed the first challenge
+	ReasonCodeFirstChallenge = "first_challenge"
+	// Defender completed a defense flow
+	ReasonCodeDefenseFlow = "defense_flow"
+	// Defender was offline when the attack started
+	ReasonCodeDefenderOffline = "defender_offline"
+	// Defender did not answer in time
+	ReasonCodeDefenseTimeout = "defense_timeout"
+	// Attacker solved a challenge for the first time
+	ReasonCodeFirstSolution = "first_solution"
+	// Attacker was the first one to solve a challenge
+	ReasonCodeFirstBlood = "first_blood"
+	// Attacker solved the 5th, 10th, 15th (etc..) unique challenge
+	ReasonCodeFifthUniqueSolution = "fifth_unique_solution"
+	// Challenge was solved by at least one but at most half of the attackers
+	ReasonCodeContestedChallenge = "contested_challenge"
+	// Attacker team solved at least 80 percent of the challenges
+	ReasonCodeAttackerSuccess = "attacker_success"
+	// Challenge was solved by every attacker
+	ReasonCodeFullCompletion = "full_completion"
+	// Uptime of the defender team
+	ReasonCodeUptime = "uptime"
+	// Defender contradicted the example of its own challenge in an audit
+	ReasonCodeAuditFailed = "audit_failed"
+	// Rejected solution was re-scored after a failed audit of the defender
+	ReasonCodeAuditRescore = "audit_rescore"
+	// Module of a hosted challenge failed to serve an attack
+	ReasonCodeModuleFailed = "module_failed"
+	// Manual adjustment of the facilitator
+	ReasonCodeAdminAdjustment = "admin_adjustment"
+)
+
 // Describes a scoreboard in the system
 type Model struct {
 	// Either 'attacker' or 'defender'
@@ -8,3 +44,31 @@ type Model struct {
 	// Overall score of the team
 	OverallScore int
 }
+
+// Describes why points were given
+type Reason struct {
+	// Use the reason code enums
+	Code string
+	// ID of the related combat if there is any
+	CombatID string
+	// ID of the related challenge if there is any
+	ChallengeID string
+	// Human readable description
+	Message string
+}
+
+// Describes a single change of the scores
+// NOTE: Entries are never modified, the totals are derived from them
+type Entry struct {
+	// Time of the change
+	At time.Time
+	// ID of the player, empty if only the team received the points
+	PlayerID string
+	// Team receiving the points
+	// Use Team enums from player package
+	Team string
+	// Points given, negative for penalties
+	Delta int
+	// Why the points were given
+	Reason Reason
+}
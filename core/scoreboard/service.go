@@ -2,8 +2,10 @@ package scoreboard
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/riltech/centurion/core/player"
+	"github.com/sirupsen/logrus"
 )
 
 // Describes a scoreboard service interface
@@ -11,52 +13,165 @@ type IService interface {
 	// Returns the score board for each team
 	GetBoards() (attacker Model, defender Model)
 	// Adds a given point to a team and to a player
-	AddPoint(playerID string, point int) error
+	// NOTE: The reason is recorded in the ledger with the points
+	AddPoint(playerID string, point int, reason Reason) error
 	// Awards a team certain amount of points
 	// NOTE: Use team enums from player package
-	AwardTeam(team string, points int, reason string)
+	AwardTeam(team string, points int, reason Reason)
+	// Adds a given (possibly negative) amount of points to a team
+	// without any player involved
+	// NOTE: Use team enums from player package
+	AdjustTeam(team string, points int, reason Reason) error
+	// Returns the ledger entries of a given player
+	GetPlayerLedger(playerID string) []Entry
+	// Returns the ledger entries of a given team
+	// including the points of its players
+	// NOTE: Use team enums from player package
+	GetTeamLedger(team string) []Entry
+	// Returns the scoring rules of the game
+	GetRules() Rules
+	// Freezes the scores, no points can be given afterwards
+	Freeze()
 }
 
 // Service implementation
 type Service struct {
 	repository    IRepository
 	playerService player.IService
+	rules         Rules
+
+	frozen bool
+	mux    sync.RWMutex
+	// Serialises the ledger writes of players
+	// so their derived scores are always up to date
+	playerMux sync.Mutex
 }
 
 // Interface check
 var _ IService = (*Service)(nil)
 
 // Constructor for the scoreboard service
-func NewService(repository IRepository, playerService player.IService) IService {
+func NewService(repository IRepository, playerService player.IService, rules Rules) IService {
 	return &Service{
 		repository:    repository,
 		playerService: playerService,
+		rules:         rules,
+		mux:           sync.RWMutex{},
 	}
 }
 
-func (s Service) GetBoards() (Model, Model) {
-	return s.repository.GetBoards()
+// Returns if the scores are frozen already
+func (s *Service) isFrozen() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.frozen
+}
+
+func (s *Service) GetBoards() (Model, Model) {
+	attacker := Model{Team: player.TeamTypeAttacker}
+	defender := Model{Team: player.TeamTypeDefender}
+	for _, entry := range s.repository.GetEntries() {
+		switch entry.Team {
+		case player.TeamTypeAttacker:
+			attacker.OverallScore += entry.Delta
+		case player.TeamTypeDefender:
+			defender.OverallScore += entry.Delta
+		}
+	}
+	return attacker, defender
 }
 
-func (s Service) AddPoint(playerID string, points int) error {
-	p, err := s.playerService.AddPoint(playerID, points)
+func (s *Service) AddPoint(playerID string, points int, reason Reason) error {
+	if s.isFrozen() {
+		return fmt.Errorf("Scores are frozen, %d points are not given to %s", points, playerID)
+	}
+	if points == 0 {
+		// Award is disabled by the rules
+		return nil
+	}
+	p, err := s.playerService.FindByID(playerID)
 	if err != nil {
 		return err
 	}
-	s.repository.AddPoint(p.Team, points)
+	s.playerMux.Lock()
+	defer s.playerMux.Unlock()
+	if _, err = s.repository.Append(Entry{
+		PlayerID: p.ID,
+		Team:     p.Team,
+		Delta:    points,
+		Reason:   reason,
+	}); err != nil {
+		return err
+	}
+	// The score of the player is derived from the ledger
+	if _, err = s.playerService.SetScore(p.ID, sum(s.GetPlayerLedger(p.ID))); err != nil {
+		return err
+	}
+	logrus.Infof("%s received %d points for %s", p.Name, points, reason.Message)
 	return nil
 }
 
-func (s Service) AwardTeam(team string, points int, reason string) {
+func (s *Service) AwardTeam(team string, points int, reason Reason) {
 	if points < 1 {
 		return
 	}
-	if team == player.TeamTypeAttacker {
-		s.repository.AddPoint(team, points)
-		fmt.Printf("Attacker team awarded %d points for %s\n", points, reason)
+	if err := s.AdjustTeam(team, points, reason); err != nil {
+		logrus.Warn(err)
+		return
 	}
-	if team == player.TeamTypeDefender {
-		s.repository.AddPoint(team, points)
-		fmt.Printf("Defender team awarded %d points for %s\n", points, reason)
+	logrus.Infof("%s team awarded %d points for %s", team, points, reason.Message)
+}
+
+func (s *Service) AdjustTeam(team string, points int, reason Reason) error {
+	if s.isFrozen() {
+		return fmt.Errorf("Scores are frozen, %d points are not given to %s team", points, team)
+	}
+	if team != player.TeamTypeAttacker && team != player.TeamTypeDefender {
+		return fmt.Errorf("%s is not a valid team", team)
+	}
+	_, err := s.repository.Append(Entry{
+		Team:   team,
+		Delta:  points,
+		Reason: reason,
+	})
+	return err
+}
+
+func (s *Service) GetPlayerLedger(playerID string) []Entry {
+	entries := []Entry{}
+	for _, entry := range s.repository.GetEntries() {
+		if entry.PlayerID == playerID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (s *Service) GetTeamLedger(team string) []Entry {
+	entries := []Entry{}
+	for _, entry := range s.repository.GetEntries() {
+		if entry.Team == team {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (s *Service) GetRules() Rules {
+	return s.rules
+}
+
+func (s *Service) Freeze() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.frozen = true
+}
+
+// Returns the sum of the points in given entries
+func sum(entries []Entry) int {
+	total := 0
+	for _, entry := range entries {
+		total += entry.Delta
 	}
+	return total
 }
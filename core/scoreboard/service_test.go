@@ -0,0 +1,78 @@
+package scoreboard
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger(t *testing.T) {
+	playerService := player.NewService(player.NewRepository())
+	assert.Nil(t, playerService.AddPlayer(player.Model{
+		ID:   "xxx",
+		Name: "John",
+		Team: player.TeamTypeAttacker,
+	}))
+	service := NewService(NewRepository(), playerService, DefaultRules())
+
+	assert.NotNil(t, service.AddPoint("unknown", 1, Reason{Code: ReasonCodeFirstSolution}))
+	assert.Nil(t, service.AddPoint("xxx", 1, Reason{
+		Code:        ReasonCodeFirstSolution,
+		CombatID:    "combat",
+		ChallengeID: "challenge",
+	}))
+	assert.Nil(t, service.AddPoint("xxx", -3, Reason{Code: ReasonCodeAdminAdjustment}))
+	service.AwardTeam(player.TeamTypeAttacker, 5, Reason{Code: ReasonCodeAttackerSuccess})
+	// Empty awards are not recorded
+	service.AwardTeam(player.TeamTypeDefender, 0, Reason{Code: ReasonCodeUptime})
+	assert.NotNil(t, service.AdjustTeam("unknown", 1, Reason{Code: ReasonCodeAdminAdjustment}))
+
+	// Totals are derived from the ledger
+	p, _ := playerService.FindByID("xxx")
+	assert.Equal(t, -2, p.Score)
+	attackers, defenders := service.GetBoards()
+	assert.Equal(t, 3, attackers.OverallScore)
+	assert.Equal(t, 0, defenders.OverallScore)
+
+	ledger := service.GetPlayerLedger("xxx")
+	assert.Len(t, ledger, 2)
+	assert.Equal(t, "combat", ledger[0].Reason.CombatID)
+	assert.Equal(t, "challenge", ledger[0].Reason.ChallengeID)
+	assert.False(t, ledger[0].At.IsZero())
+	assert.Len(t, service.GetTeamLedger(player.TeamTypeAttacker), 3)
+	assert.Empty(t, service.GetTeamLedger(player.TeamTypeDefender))
+
+	// Nothing is recorded after the scores are frozen
+	service.Freeze()
+	assert.NotNil(t, service.AddPoint("xxx", 1, Reason{Code: ReasonCodeFirstSolution}))
+	service.AwardTeam(player.TeamTypeAttacker, 1, Reason{Code: ReasonCodeFullCompletion})
+	assert.Len(t, service.GetTeamLedger(player.TeamTypeAttacker), 3)
+}
+
+func TestPersistentRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	repo, err := NewPersistentRepository(store)
+	assert.Nil(t, err)
+	_, err = repo.Append(Entry{
+		PlayerID: "xxx",
+		Team:     player.TeamTypeDefender,
+		Delta:    1,
+		Reason:   Reason{Code: ReasonCodeDefenseFlow, CombatID: "combat"},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Close())
+
+	store, err = storage.NewBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	repo, err = NewPersistentRepository(store)
+	assert.Nil(t, err)
+	entries := repo.GetEntries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "combat", entries[0].Reason.CombatID)
+}
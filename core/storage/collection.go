@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Describes records stored one by one in a bucket of a store
+// keeping the order they were added in, so a change of a record
+// only writes the record itself
+// NOTE: Collections are not thread safe, they are expected to be
+// used under the lock of the repository owning them
+type Collection struct {
+	bucket string
+	// Store keys of the records by their ID
+	keys map[string]string
+	// Sequence number of the last added record
+	last uint64
+}
+
+// Constructor for a collection stored in a given bucket
+func NewCollection(bucket string) *Collection {
+	return &Collection{
+		bucket: bucket,
+		keys:   map[string]string{},
+	}
+}
+
+// Loads every record of the collection from a given store in the order they were added
+// decode is called for every record with the function loading it and returns its ID
+func (c *Collection) Load(store IStore, decode func(load func(value interface{}) error) (string, error)) error {
+	return store.ForEach(c.bucket, func(key string, load func(value interface{}) error) error {
+		seq, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid key %s in %s: %w", key, c.bucket, err)
+		}
+		ID, err := decode(load)
+		if err != nil {
+			return err
+		}
+		c.keys[ID] = key
+		if seq > c.last {
+			c.last = seq
+		}
+		return nil
+	})
+}
+
+// Writes a given record, records not stored yet are added after the others
+func (c *Collection) Put(tx ITx, ID string, value interface{}) error {
+	key, ok := c.keys[ID]
+	if !ok {
+		c.last++
+		// Zero padded keys keep the order of the sequence
+		key = fmt.Sprintf("%020d", c.last)
+		c.keys[ID] = key
+	}
+	return tx.Put(c.bucket, key, value)
+}
+
+// Deletes a given record
+func (c *Collection) Delete(tx ITx, ID string) error {
+	key, ok := c.keys[ID]
+	if !ok {
+		return nil
+	}
+	delete(c.keys, ID)
+	return tx.Delete(c.bucket, key)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Keeps every repository state in memory only
+const StorageTypeMemory = "memory"
+
+// Persists repository states in an embedded bbolt database
+const StorageTypeBolt = "bolt"
+
+// Name of the bucket the single values are stored in
+var bucketName = []byte("centurion")
+
+// Time to wait for the database file when another process holds it
+const openTimeout = 5 * time.Second
+
+// Describes a key-value store which repositories can use
+// to persist their state between restarts
+type IStore interface {
+	// Saves a given value under a given key
+	Save(key string, value interface{}) error
+	// Loads the value stored under a given key into value
+	// returns false if nothing is stored under the key yet
+	Load(key string, value interface{}) (bool, error)
+	// Runs a given function in a single write transaction
+	// NOTE: Either every write of the function is stored or none of them
+	Update(fn func(tx ITx) error) error
+	// Calls a given function with every record of a given bucket in the order of their keys
+	// decode loads the record into a given value
+	ForEach(bucket string, fn func(key string, decode func(value interface{}) error) error) error
+	// Closes the underlying storage
+	Close() error
+}
+
+// Describes the writes of a transaction
+type ITx interface {
+	// Saves a given value under a given key
+	Save(key string, value interface{}) error
+	// Saves a given record under a given key of a given bucket
+	// NOTE: The bucket is created if it does not exist yet
+	Put(bucket string, key string, value interface{}) error
+	// Deletes the record under a given key of a given bucket
+	Delete(bucket string, key string) error
+}
+
+// Store implementation over an embedded bbolt database
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// Interface check
+var _ IStore = (*BoltStore)(nil)
+
+// Transaction implementation over a bbolt transaction
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// Interface check
+var _ ITx = (*boltTx)(nil)
+
+// Constructor for a bbolt based store
+// NOTE: The database file is created if it does not exist yet
+func NewBoltStore(path string) (IStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("Could not open %s (is another instance running?): %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db}, nil
+}
+
+func (s *BoltStore) Save(key string, value interface{}) error {
+	return s.Update(func(tx ITx) error {
+		return tx.Save(key, value)
+	})
+}
+
+func (s *BoltStore) Load(key string, value interface{}) (bool, error) {
+	if s == nil {
+		return false, fmt.Errorf("Store is not initialised")
+	}
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName).Get([]byte(key))
+		if b == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(b, value)
+	})
+	return found, err
+}
+
+func (s *BoltStore) Update(fn func(tx ITx) error) error {
+	if s == nil {
+		return fmt.Errorf("Store is not initialised")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx})
+	})
+}
+
+func (s *BoltStore) ForEach(bucket string, fn func(key string, decode func(value interface{}) error) error) error {
+	if s == nil {
+		return fmt.Errorf("Store is not initialised")
+	}
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(string(k), func(value interface{}) error {
+				return json.Unmarshal(v, value)
+			})
+		})
+	})
+}
+
+func (s *BoltStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (t *boltTx) Save(key string, value interface{}) error {
+	return t.Put(string(bucketName), key, value)
+}
+
+func (t *boltTx) Put(bucket string, key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	bkt, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	return bkt.Put([]byte(key), b)
+}
+
+func (t *boltTx) Delete(bucket string, key string) error {
+	bkt := t.tx.Bucket([]byte(bucket))
+	if bkt == nil {
+		return nil
+	}
+	return bkt.Delete([]byte(key))
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewBoltStore(path)
+	assert.Nil(t, err)
+	var value []string
+	found, err := store.Load("foo", &value)
+	assert.Nil(t, err)
+	assert.False(t, found)
+	assert.Nil(t, store.Save("foo", []string{"bar", "baz"}))
+	assert.Nil(t, store.Close())
+
+	store, err = NewBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	found, err = store.Load("foo", &value)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []string{"bar", "baz"}, value)
+}
+
+func TestUpdate(t *testing.T) {
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "test.db"))
+	assert.Nil(t, err)
+	defer store.Close()
+	assert.NotNil(t, store.Update(func(tx ITx) error {
+		assert.Nil(t, tx.Save("foo", "bar"))
+		assert.Nil(t, tx.Put("records", "1", "bar"))
+		return fmt.Errorf("Failure")
+	}))
+	found, err := store.Load("foo", new(string))
+	assert.Nil(t, err)
+	assert.False(t, found)
+	assert.Nil(t, store.ForEach("records", func(key string, decode func(value interface{}) error) error {
+		t.Errorf("Unexpected record %s", key)
+		return nil
+	}))
+}
+
+func TestCollection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewBoltStore(path)
+	assert.Nil(t, err)
+	records := NewCollection("records")
+	for _, ID := range []string{"c", "a", "b"} {
+		ID := ID
+		assert.Nil(t, store.Update(func(tx ITx) error {
+			return records.Put(tx, ID, ID+"1")
+		}))
+	}
+	assert.Nil(t, store.Update(func(tx ITx) error {
+		if err := records.Put(tx, "a", "a2"); err != nil {
+			return err
+		}
+		return records.Delete(tx, "b")
+	}))
+	assert.Nil(t, store.Close())
+
+	store, err = NewBoltStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	records = NewCollection("records")
+	loaded := []string{}
+	assert.Nil(t, records.Load(store, func(load func(interface{}) error) (string, error) {
+		var value string
+		err := load(&value)
+		loaded = append(loaded, value)
+		return value[:1], err
+	}))
+	assert.Equal(t, []string{"c1", "a2"}, loaded)
+	// New records are added after the loaded ones
+	assert.Nil(t, store.Update(func(tx ITx) error {
+		return records.Put(tx, "d", "d1")
+	}))
+	loaded = []string{}
+	assert.Nil(t, store.ForEach("records", func(key string, decode func(value interface{}) error) error {
+		var value string
+		err := decode(&value)
+		loaded = append(loaded, value)
+		return err
+	}))
+	assert.Equal(t, []string{"c1", "a2", "d1"}, loaded)
+}
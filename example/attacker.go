@@ -160,7 +160,8 @@ func (a Attacker) Start() {
 		SocketEvent: dto.SocketEvent{
 			Type: dto.SocketEventTypeJoin,
 		},
-		ID: regResponseDTO.ID,
+		ID:    regResponseDTO.ID,
+		Token: regResponseDTO.Token,
 	}
 	if err = conn.WriteJSON(join); err != nil {
 		logger.LogError(err)
@@ -214,6 +215,7 @@ func (a Attacker) Start() {
 					Hints:     detailedEvent.Hints,
 					Solutions: []interface{}{solution},
 					TargetID:  detailedEvent.TargetID,
+					CombatID:  detailedEvent.CombatID,
 				})
 				continue
 			default:
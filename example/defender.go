@@ -94,7 +94,8 @@ func (d Defender) Start() {
 		SocketEvent: dto.SocketEvent{
 			Type: dto.SocketEventTypeJoin,
 		},
-		ID: regResponseDTO.ID,
+		ID:    regResponseDTO.ID,
+		Token: regResponseDTO.Token,
 	}
 	if err = conn.WriteJSON(join); err != nil {
 		logger.LogError(err)
@@ -104,6 +105,7 @@ func (d Defender) Start() {
 
 	customChallengeDTO := dto.InstallChallengeRequest{
 		DefenderID:  regResponseDTO.ID,
+		Token:       regResponseDTO.Token,
 		Name:        "Reverse sorter - 2",
 		Description: "You do the same as in reverse sorter 1, this is a demo challenge",
 		Example: dto.ChallengeExampleDTO{
@@ -163,6 +165,17 @@ func (d Defender) Start() {
 			}
 			continue
 		}
+		if event.Type == dto.SocketEventTypeChallengeVerification {
+			var detailedEvent dto.ChallengeVerificationEvent
+			if err = json.Unmarshal(message, &detailedEvent); err != nil {
+				logger.LogError(err)
+				continue
+			}
+			if !detailedEvent.Success {
+				logger.LogError(fmt.Errorf("Challenge failed the verification: %s", detailedEvent.Message))
+			}
+			continue
+		}
 		if event.Type == dto.SocketEventTypeSolutionEvaluationRequest {
 			var detailedEvent dto.SolutionEvaluationRequestEvent
 			if err = json.Unmarshal(message, &detailedEvent); err != nil {
@@ -218,7 +231,7 @@ func (d Defender) Start() {
 				}
 				continue
 			}
-			if hint != "12345678910" || solution != "01987654321" {
+			if solution != reverse(hint) {
 				if err = conn.WriteJSON(dto.SolutionEvaluationEvent{
 					SocketEvent: dto.SocketEvent{
 						Type: dto.SocketEventTypeSolutionEvaluation,
@@ -247,3 +260,12 @@ func (d Defender) Start() {
 		}
 	}
 }
+
+// Returns a given string in reverse order
+func reverse(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
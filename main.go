@@ -6,16 +6,77 @@ import (
 	"sync"
 	"time"
 
+	"github.com/brianvoe/gofakeit/v6"
 	"github.com/riltech/centurion/core"
+	"github.com/riltech/centurion/core/auth"
 	"github.com/riltech/centurion/core/bus"
+	"github.com/riltech/centurion/core/challenge"
 	"github.com/riltech/centurion/core/combat"
 	"github.com/riltech/centurion/core/config"
+	"github.com/riltech/centurion/core/engine"
+	"github.com/riltech/centurion/core/game"
 	"github.com/riltech/centurion/core/player"
+	"github.com/riltech/centurion/core/presence"
+	"github.com/riltech/centurion/core/sandbox"
 	"github.com/riltech/centurion/core/scoreboard"
+	"github.com/riltech/centurion/core/storage"
 	"github.com/riltech/centurion/example"
 	"github.com/sirupsen/logrus"
 )
 
+// Collection of the repositories used in the game
+type repositories struct {
+	player    player.IRepository
+	challenge challenge.IRepository
+	combat    combat.IRepository
+	score     scoreboard.IRepository
+	game      game.IRepository
+	presence  presence.IRepository
+}
+
+// Creates the repositories based on the configured storage
+// returns a nil store if the game runs in memory only
+func createRepositories(spec *config.Specification) (*repositories, storage.IStore, error) {
+	switch spec.Storage {
+	case storage.StorageTypeMemory:
+		return &repositories{
+			player:    player.NewRepository(),
+			challenge: challenge.NewRepository(),
+			combat:    combat.NewRepository(),
+			score:     scoreboard.NewRepository(),
+			game:      game.NewRepository(),
+			presence:  presence.NewRepository(),
+		}, nil, nil
+	case storage.StorageTypeBolt:
+	default:
+		return nil, nil, fmt.Errorf("Unknown storage type: %s", spec.Storage)
+	}
+	store, err := storage.NewBoltStore(spec.StoragePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	repos := &repositories{}
+	if repos.player, err = player.NewPersistentRepository(store); err != nil {
+		return nil, nil, err
+	}
+	if repos.challenge, err = challenge.NewPersistentRepository(store); err != nil {
+		return nil, nil, err
+	}
+	if repos.combat, err = combat.NewPersistentRepository(store); err != nil {
+		return nil, nil, err
+	}
+	if repos.score, err = scoreboard.NewPersistentRepository(store); err != nil {
+		return nil, nil, err
+	}
+	if repos.game, err = game.NewPersistentRepository(store); err != nil {
+		return nil, nil, err
+	}
+	if repos.presence, err = presence.NewPersistentRepository(store); err != nil {
+		return nil, nil, err
+	}
+	return repos, store, nil
+}
+
 func main() {
 	file, err := os.Create("logs")
 	if err != nil {
@@ -33,26 +94,112 @@ func main() {
 	if err != nil {
 		logrus.Fatal(err)
 	}
+	repos, store, err := createRepositories(spec)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if store != nil {
+		logrus.Infof("Game state is persisted in %s", spec.StoragePath)
+		defer func() {
+			if err := store.Close(); err != nil {
+				logrus.Error(err)
+			}
+		}()
+	}
+	secret := []byte(spec.TokenSecret)
+	if len(secret) == 0 {
+		if secret, err = auth.LoadOrCreateSecret(store); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+	if !engine.IsValidSessionPolicy(spec.SessionPolicy) {
+		logrus.Fatalf("Unknown session policy %s", spec.SessionPolicy)
+	}
+	if spec.HeartbeatInterval > 0 && spec.HeartbeatTimeout <= spec.HeartbeatInterval {
+		logrus.Fatal("Heartbeat timeout has to be longer than the heartbeat interval")
+	}
+	if spec.AuditChance < 0 || spec.AuditChance > 1 {
+		logrus.Fatal("Audit chance has to be between 0 and 1")
+	}
+	if spec.AttackLimit < 0 || spec.ChallengeAttackLimit < 0 || spec.FailureCooldown < 0 {
+		logrus.Fatal("Attack limits and the failure cooldown cannot be negative")
+	}
+	if (spec.AttackLimit > 0 || spec.ChallengeAttackLimit > 0) && spec.AttackWindow <= 0 {
+		logrus.Fatal("Attack window has to be positive when the attacks are limited")
+	}
+	if spec.ModuleTimeout <= 0 {
+		logrus.Fatal("Module timeout has to be positive")
+	}
+	// A WebAssembly memory is limited to 4GiB
+	if spec.ModuleMemory <= 0 || spec.ModuleMemory > 4096 {
+		logrus.Fatal("Module memory has to be between 1 and 4096 MiB")
+	}
+	rules, err := scoreboard.LoadRules(spec.ScoringRules)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	engineSettings := engine.Settings{
+		SessionPolicy:     spec.SessionPolicy,
+		HeartbeatInterval: spec.HeartbeatInterval,
+		HeartbeatTimeout:  spec.HeartbeatTimeout,
+		AuditInterval:     spec.AuditInterval,
+		AuditChance:       spec.AuditChance,
+		RateLimits: engine.RateLimits{
+			Window:          spec.AttackWindow,
+			AttackerLimit:   spec.AttackLimit,
+			ChallengeLimit:  spec.ChallengeAttackLimit,
+			FailureCooldown: spec.FailureCooldown,
+		},
+	}
 	exitHandler := core.NewExitHandler()
 	bus := bus.NewBus()
-	playerRepo := player.NewRepository()
-	playerService := player.NewService(playerRepo)
-	scoreRepository := scoreboard.NewRepository()
-	scoreService := scoreboard.NewService(scoreRepository, playerService)
-	combatRepository := combat.NewRepository()
-	combatService := combat.NewService(combatRepository)
+	playerService := player.NewService(repos.player)
+	scoreService := scoreboard.NewService(repos.score, playerService, rules)
+	combatService := combat.NewService(repos.combat, combat.Timeouts{
+		DefenseRequested:            spec.CombatDefenseTimeout,
+		AttackerChallenged:          spec.CombatAttackTimeout,
+		SolutionEvaluationRequested: spec.CombatEvaluationTimeout,
+		ReaperInterval:              spec.CombatReaperInterval,
+	})
+	if spec.HintSeed != 0 {
+		logrus.Infof("Hints of the default modules are generated with the fixed seed %d", spec.HintSeed)
+	}
+	challengeService, err := challenge.NewServiceWithModules(
+		repos.challenge,
+		challenge.NewDefaultRegistry(),
+		spec.DefaultPack,
+		gofakeit.New(spec.HintSeed))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	gameService := game.NewService(bus, repos.game, spec.GameDuration, spec.CountdownMarks)
+	presenceService := presence.NewService(repos.presence)
+	sandboxService := sandbox.NewService(sandbox.Limits{
+		Timeout: spec.ModuleTimeout,
+		// A page of WebAssembly memory is 64KiB
+		MemoryPages: uint32(spec.ModuleMemory * 16),
+	}, spec.HintSeed)
 	engine := core.NewEngine(
 		spec.Port,
 		bus,
 		scoreService,
 		combatService,
 		playerService,
+		challengeService,
+		gameService,
+		presenceService,
+		sandboxService,
+		auth.NewService(secret),
+		spec.AdminToken,
+		engineSettings,
 	)
 	dashboard := core.NewDashboard(
 		bus,
 		scoreService,
 		combatService,
 		playerService,
+		gameService,
+		presenceService,
 	)
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
@@ -61,6 +208,9 @@ func main() {
 	exitHandler.On(func() {
 		logrus.Info("Running exit handler")
 		engine.Stop()
+		if err := sandboxService.Close(); err != nil {
+			logrus.Error(err)
+		}
 		bus.Stop()
 		if exampleAttacker != nil {
 			exampleAttacker.Stop()
@@ -73,6 +223,11 @@ func main() {
 		wg.Done()
 	})
 	go engine.Start()
+	if spec.AutoStart && gameService.GetPhase() == game.PhaseLobby {
+		if err = gameService.SetPhase(game.PhaseRunning); err != nil {
+			logrus.Error(err)
+		}
+	}
 	if spec.ExampleEnabled {
 		exampleAttacker = example.NewAttacker("localhost:8080")
 		go func() {